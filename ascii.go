@@ -0,0 +1,29 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "encoding/binary"
+
+// asciiSpanGeneric returns the length of the prefix of d that is ASCII other
+// than NUL, checking 8 bytes at a time. It is used as asciiSpan where there
+// is no assembly version.
+func asciiSpanGeneric(d []byte) int {
+	const (
+		lo = 0x0101010101010101
+		hi = 0x8080808080808080
+	)
+
+	i := 0
+	for ; i+8 <= len(d); i += 8 {
+		w := binary.LittleEndian.Uint64(d[i:])
+
+		// stop at a byte with the high bit set, or a zero byte
+		if w&hi != 0 || (w-lo)&^w&hi != 0 {
+			break
+		}
+	}
+	for ; i < len(d) && d[i] != 0 && d[i] < 0x80; i++ {
+	}
+	return i
+}
@@ -0,0 +1,51 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+//go:build !purego && !tinygo
+
+#include "textflag.h"
+
+// func asciiSpan(d []byte) int
+TEXT ·asciiSpan(SB), NOSPLIT, $0-32
+	MOVQ d_base+0(FP), SI
+	MOVQ d_len+8(FP), BX
+	XORQ AX, AX
+	PXOR X0, X0
+
+loop:
+	// 16 bytes at a time
+	LEAQ 16(AX), DX
+	CMPQ DX, BX
+	JA   tail
+
+	MOVOU    (SI)(AX*1), X1
+	PMOVMSKB X1, CX   // bytes with the high bit set
+	PCMPEQB  X0, X1
+	PMOVMSKB X1, DX   // zero bytes
+	ORL      DX, CX
+	JNZ      found
+
+	ADDQ $16, AX
+	JMP  loop
+
+found:
+	BSFL CX, CX
+	ADDQ CX, AX
+	MOVQ AX, ret+24(FP)
+	RET
+
+tail:
+	// remaining bytes one at a time
+	CMPQ    AX, BX
+	JAE     done
+	MOVBLZX (SI)(AX*1), CX
+	TESTB   CL, CL
+	JZ      done
+	CMPB    CL, $0x80
+	JAE     done
+	INCQ    AX
+	JMP     tail
+
+done:
+	MOVQ AX, ret+24(FP)
+	RET
@@ -0,0 +1,63 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+//go:build !purego && !tinygo
+
+#include "textflag.h"
+
+// func asciiSpan(d []byte) int
+TEXT ·asciiSpan(SB), NOSPLIT, $0-32
+	MOVD  d_base+0(FP), R0
+	MOVD  d_len+8(FP), R1
+	MOVD  $0, R2
+	VEOR  V3.B16, V3.B16, V3.B16
+	VMOVI $128, V2.B16
+
+loop:
+	// 16 bytes at a time
+	ADD $16, R2, R3
+	CMP R1, R3
+	BHI tail
+
+	ADD    R0, R2, R4
+	VLD1   (R4), [V0.B16]
+	VCMEQ  V0.B16, V3.B16, V4.B16 // zero bytes
+	VCMTST V0.B16, V2.B16, V5.B16 // bytes with the high bit set
+	VORR   V4.B16, V5.B16, V4.B16
+	VMOV   V4.D[0], R5
+	VMOV   V4.D[1], R6
+	ORR    R5, R6, R7
+	CBNZ   R7, found
+
+	MOVD R3, R2
+	B    loop
+
+found:
+	// the first marked byte is the lowest set bit
+	CBZ  R5, high
+	RBIT R5, R5
+	CLZ  R5, R5
+	ADD  R5>>3, R2, R2
+	B    done
+
+high:
+	RBIT R6, R6
+	CLZ  R6, R6
+	ADD  $8, R2, R2
+	ADD  R6>>3, R2, R2
+	B    done
+
+tail:
+	// remaining bytes one at a time
+	CMP   R1, R2
+	BHS   done
+	MOVBU (R0)(R2), R5
+	CBZ   R5, done
+	CMP   $0x80, R5
+	BHS   done
+	ADD   $1, R2, R2
+	B     tail
+
+done:
+	MOVD R2, ret+24(FP)
+	RET
@@ -0,0 +1,12 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+//go:build (!amd64 && !arm64) || purego || tinygo
+
+package jutf
+
+// asciiSpan returns the length of the prefix of d that is ASCII other than
+// NUL.
+func asciiSpan(d []byte) int {
+	return asciiSpanGeneric(d)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestASCIISpan(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"java/lang/Object", 16},
+		{"java/lang/Object\x00", 16},
+		{"java/lang\x00Object", 9},
+		{"java/lang/Obj\xc0\x80ect", 13},
+		{"\x7f\x7f\x7f\x7f\x7f\x7f\x7f\x7f\x80", 8},
+		{"\x01\x01\x01\x01\x01\x01\x01\x00", 7},
+	}
+	for _, tt := range tests {
+		if got := asciiSpan([]byte(tt.data)); got != tt.want {
+			t.Errorf("asciiSpan(%q) = %d, want %d", tt.data, got, tt.want)
+		}
+		if got := asciiSpanGeneric([]byte(tt.data)); got != tt.want {
+			t.Errorf("asciiSpanGeneric(%q) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestASCIISpanStop(t *testing.T) {
+	// a stop byte at every position of inputs spanning several blocks
+	for _, stop := range []byte{0, 0x80, 0xc0, 0xff} {
+		for n := 0; n < 70; n++ {
+			for at := 0; at <= n; at++ {
+				d := bytes.Repeat([]byte{0x7f}, n)
+				if at < n {
+					d[at] = stop
+				}
+				if got := asciiSpan(d); got != at {
+					t.Fatalf("asciiSpan(%x) = %d, want %d", d, got, at)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkValid(b *testing.B) {
+	d := bytes.Repeat([]byte("Ljava/lang/Object;"), 1000)
+	b.SetBytes(int64(len(d)))
+	for n := 0; n < b.N; n++ {
+		Valid(d)
+	}
+}
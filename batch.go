@@ -0,0 +1,74 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "unsafe"
+
+// EncodeAll encodes each of ss like Encode. The results share a single
+// allocation, so they must be treated as read-only slices of it.
+func EncodeAll(ss []string, opts ...Option) [][]byte {
+	o := newOptions(opts)
+
+	n := 0
+	for _, s := range ss {
+		n += encodedLen(s, &o)
+	}
+
+	buf := make([]byte, 0, n)
+	out := make([][]byte, len(ss))
+	for k, s := range ss {
+		start := len(buf)
+		buf = encode(buf, s, &o)
+		out[k] = buf[start:len(buf):len(buf)]
+	}
+	return out
+}
+
+// DecodeAll decodes each of ds like Decode. The results share a single
+// allocation. On error, the results so far are returned along with it, so
+// that the length of the result is the index of the input that failed.
+func DecodeAll(ds [][]byte, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+
+	// inputs from the first one over the limits on are not decoded, nor
+	// counted in the allocation
+	var limitErr error
+	n := 0
+	for k, d := range ds {
+		if limitErr = o.checkLimits(d); limitErr != nil {
+			ds = ds[:k]
+			break
+		}
+
+		// the output is only longer than the input with Lossy
+		if o.onError == Replace {
+			n += DecodedLen(d)
+		} else {
+			n += len(d)
+		}
+	}
+
+	buf := make([]byte, 0, n)
+	out := make([]string, 0, len(ds))
+	for _, d := range ds {
+		start := len(buf)
+
+		skip := o.bomLen(d)
+		i := same(d, skip, len(d), &o)
+		buf = append(buf, d[skip:i]...)
+		if i < len(d) {
+			var err error
+			if buf, err = decode(buf, d, i, len(d), &o); err != nil {
+				return out, err
+			}
+		}
+
+		if len(buf) > start {
+			out = append(out, unsafe.String(&buf[start], len(buf)-start))
+		} else {
+			out = append(out, "")
+		}
+	}
+	return out, limitErr
+}
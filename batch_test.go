@@ -0,0 +1,65 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncodeAllDecodeAll(t *testing.T) {
+	ss := []string{"java/lang/Object", "", "a\x00b", "\U0001f4a9", "日本語"}
+
+	encoded := EncodeAll(ss)
+	if len(encoded) != len(ss) {
+		t.Fatalf("EncodeAll() = %d results, want %d", len(encoded), len(ss))
+	}
+	for k, s := range ss {
+		if string(encoded[k]) != string(Encode(s)) {
+			t.Errorf("EncodeAll()[%d] = %x, want %x", k, encoded[k], Encode(s))
+		}
+	}
+
+	// appending to one result must not overwrite the next
+	_ = append(encoded[0], 'x')
+	if string(encoded[1]) != "" || string(encoded[2]) != string(Encode(ss[2])) {
+		t.Errorf("EncodeAll() results overlap")
+	}
+
+	decoded, err := DecodeAll(encoded)
+	if err != nil || len(decoded) != len(ss) {
+		t.Fatalf("DecodeAll() = %q, %v", decoded, err)
+	}
+	for k, s := range ss {
+		if decoded[k] != s {
+			t.Errorf("DecodeAll()[%d] = %q, want %q", k, decoded[k], s)
+		}
+	}
+}
+
+func TestDecodeAllError(t *testing.T) {
+	ds := [][]byte{[]byte("ok"), {0xff}, []byte("never")}
+	got, err := DecodeAll(ds)
+	if !errors.Is(err, ErrInvalidEncoding) || len(got) != 1 || got[0] != "ok" {
+		t.Errorf("DecodeAll() = %q, %v", got, err)
+	}
+
+	got, err = DecodeAll(ds, Lossy())
+	if err != nil || len(got) != 3 || got[1] != "�" {
+		t.Errorf("DecodeAll(Lossy) = %q, %v", got, err)
+	}
+
+	got, err = DecodeAll([][]byte{[]byte("ok"), []byte("long"), []byte("x")}, MaxLen(3))
+	if !errors.Is(err, ErrTooLarge) || len(got) != 1 || got[0] != "ok" {
+		t.Errorf("DecodeAll(MaxLen) = %q, %v", got, err)
+	}
+}
+
+func TestDecodeAllAllocs(t *testing.T) {
+	ds := EncodeAll([]string{"a\x00", "b\U0001f4a9", "c"})
+	// the result slice and the shared buffer
+	if n := testing.AllocsPerRun(10, func() { DecodeAll(ds) }); n != 2 {
+		t.Errorf("DecodeAll allocates %v times, want 2", n)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strings"
+	"testing"
+)
+
+// corpus is a set of inputs resembling real workloads, each about 1 MB.
+var corpus = []struct {
+	name string
+	text string
+}{
+	{"identifiers", repeat("Ljava/lang/Object; java/util/concurrent/ConcurrentHashMap$Node <init> ")},
+	{"CJK", repeat("日本語のテキストと中文文本，还有한국어 텍스트. ")},
+	{"emoji", repeat("lol 😂😂 see you 🙂👍 at 8? 🎉🎉🎉 ")},
+	{"NUL", repeat("key\x00value\x00\x00\x00\x01\x02\x03\x00")},
+	{"mixed", repeat("Hello\x00Wörld!!! 日本語 \U0001f4a9 ")},
+}
+
+func repeat(s string) string {
+	return strings.Repeat(s, (1<<20)/len(s))
+}
+
+func BenchmarkCorpusEncode(b *testing.B) {
+	for _, c := range corpus {
+		b.Run(c.name, func(b *testing.B) {
+			b.SetBytes(int64(len(c.text)))
+			for n := 0; n < b.N; n++ {
+				Encode(c.text)
+			}
+		})
+	}
+}
+
+func BenchmarkCorpusDecode(b *testing.B) {
+	for _, c := range corpus {
+		d := Encode(c.text)
+		b.Run(c.name, func(b *testing.B) {
+			b.SetBytes(int64(len(d)))
+			for n := 0; n < b.N; n++ {
+				_, _ = Decode(d)
+			}
+		})
+	}
+}
+
+func BenchmarkCorpusValid(b *testing.B) {
+	for _, c := range corpus {
+		d := Encode(c.text)
+		b.Run(c.name, func(b *testing.B) {
+			b.SetBytes(int64(len(d)))
+			for n := 0; n < b.N; n++ {
+				Valid(d)
+			}
+		})
+	}
+}
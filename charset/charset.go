@@ -0,0 +1,190 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package charset makes modified UTF-8 and CESU-8 available as x/text
+// encodings, so that code that decodes by charset label can find them.
+package charset
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anders/jutf"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// Encodings. Their decoders replace malformed input with U+FFFD, like the
+// other x/text encodings, unless changed with WithPolicy; raw NULs and 4-byte
+// sequences are accepted.
+var (
+	// ModifiedUTF8 is the modified UTF-8 used by Java.
+	ModifiedUTF8 encoding.Encoding = &enc{"x-java-modified-utf-8", false, jutf.Replace}
+
+	// CESU8 is CESU-8, which differs from modified UTF-8 in that NUL is a
+	// single 0 byte.
+	CESU8 encoding.Encoding = &enc{"CESU-8", true, jutf.Replace}
+)
+
+// WithPolicy returns e, which is ModifiedUTF8 or CESU8, with a decoder that
+// handles malformed input as p says. With jutf.Fail, the decoder fails with
+// encoding.ErrInvalidUTF8. Other encodings are returned as is.
+func WithPolicy(e encoding.Encoding, p jutf.OnError) encoding.Encoding {
+	if e, ok := e.(*enc); ok {
+		c := *e
+		c.onError = p
+		return &c
+	}
+	return e
+}
+
+// names maps the labels of the encodings, in lower case, to them.
+var names = map[string]encoding.Encoding{
+	"x-java-modified-utf-8": ModifiedUTF8,
+	"java-modified-utf-8":   ModifiedUTF8,
+	"modified-utf-8":        ModifiedUTF8,
+	"mutf-8":                ModifiedUTF8,
+	"mutf8":                 ModifiedUTF8,
+	"cesu-8":                CESU8,
+	"cesu8":                 CESU8,
+	"cscesu8":               CESU8,
+	"x-cesu-8":              CESU8,
+}
+
+// Lookup returns the encoding with the given label, matched without regard
+// to case or surrounding space. The labels of ModifiedUTF8 and CESU8 are
+// found here, others through ianaindex.IANA.
+func Lookup(name string) (encoding.Encoding, error) {
+	if e, ok := names[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return e, nil
+	}
+	return ianaindex.IANA.Encoding(name)
+}
+
+// Name returns the canonical name of e, as found by Lookup.
+func Name(e encoding.Encoding) (string, error) {
+	if e, ok := e.(*enc); ok {
+		return e.name, nil
+	}
+	return ianaindex.IANA.Name(e)
+}
+
+type enc struct {
+	name    string
+	rawNUL  bool // CESU-8
+	onError jutf.OnError
+}
+
+func (e *enc) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: &decoder{rawNUL: e.rawNUL, onError: e.onError}}
+}
+
+func (e *enc) NewEncoder() *encoding.Encoder {
+	return &encoding.Encoder{Transformer: &encoder{rawNUL: e.rawNUL}}
+}
+
+func (e *enc) String() string {
+	return e.name
+}
+
+// longest sequence, a surrogate pair.
+const maxSeq = 6
+
+type decoder struct {
+	transform.NopResetter
+	rawNUL  bool
+	onError jutf.OnError
+}
+
+func (d *decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if c := src[nSrc]; c < utf8.RuneSelf {
+			if nDst == len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+			nSrc++
+			continue
+		}
+
+		r, n := jutf.DecodeRune(src[nSrc:])
+		seq := src[nSrc : nSrc+n]
+		bad := r == utf8.RuneError && string(seq) != "\ufffd" || r == 0 && d.rawNUL // C0 80 is not CESU-8
+		if bad && !atEOF && len(src)-nSrc < maxSeq && nSrc+n == len(src) {
+			// may be the start of a sequence
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		var out []byte
+		var buf [utf8.UTFMax]byte
+		switch {
+		case !bad:
+			out = buf[:utf8.EncodeRune(buf[:], r)]
+		case d.onError == jutf.Fail:
+			return nDst, nSrc, encoding.ErrInvalidUTF8
+		case d.onError == jutf.Skip:
+		case d.onError == jutf.Passthrough:
+			out = seq
+		default:
+			out = []byte("\ufffd")
+		}
+		if nDst+len(out) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], out)
+		nSrc += n
+	}
+	return nDst, nSrc, nil
+}
+
+type encoder struct {
+	transform.NopResetter
+	rawNUL bool
+}
+
+func (e *encoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		c := src[nSrc]
+		if c < utf8.RuneSelf && (c != 0 || e.rawNUL) {
+			if nDst == len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+			nSrc++
+			continue
+		}
+
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		r, n := utf8.DecodeRune(src[nSrc:])
+
+		var buf [maxSeq]byte
+		seq := buf[:0]
+		switch {
+		case r == 0:
+			seq = append(seq, 0xc0, 0x80)
+		case r > 0xffff:
+			hi, lo := jutf.EncodeSurrogatePair(r)
+			seq = appendChar(appendChar(seq, hi), lo)
+		default:
+			// invalid input was decoded as U+FFFD
+			seq = buf[:utf8.EncodeRune(buf[:], r)]
+		}
+
+		if nDst+len(seq) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], seq)
+		nSrc += n
+	}
+	return nDst, nSrc, nil
+}
+
+// appendChar appends the 3-byte encoding of the surrogate c.
+func appendChar(b []byte, c uint16) []byte {
+	return append(b, 0xe0|byte(c>>12), 0x80|byte(c>>6)&0x3f, 0x80|byte(c)&0x3f)
+}
@@ -0,0 +1,153 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package charset
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/anders/jutf"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"x-java-modified-utf-8", "x-java-modified-utf-8"},
+		{"MUTF-8", "x-java-modified-utf-8"},
+		{" CESU-8 ", "CESU-8"},
+		{"cesu8", "CESU-8"},
+		{"utf-8", "UTF-8"},
+		{"latin1", "ISO_8859-1:1987"},
+	}
+	for _, tt := range tests {
+		e, err := Lookup(tt.name)
+		if err != nil {
+			t.Errorf("Lookup(%q) error = %v", tt.name, err)
+			continue
+		}
+		if got, err := Name(e); got != tt.want || err != nil {
+			t.Errorf("Name(Lookup(%q)) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := Lookup("no-such-charset"); err == nil {
+		t.Errorf("Lookup(no-such-charset) returned no error")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		mutf string
+		cesu string
+	}{
+		{"ASCII", "abc", "abc", "abc"},
+		{"NUL", "a\xc0\x80b", "a\x00b", "a\ufffdb"},
+		{"raw NUL", "a\x00b", "a\x00b", "a\x00b"},
+		{"pair", "\xed\xa0\xbd\xed\xb2\xa9", "\U0001f4a9", "\U0001f4a9"},
+		{"four byte", "\U0001f4a9", "\U0001f4a9", "\U0001f4a9"},
+		{"lone surrogate", "\xed\xa0\xbdx", "\ufffdx", "\ufffdx"},
+		{"invalid", "a\xffb", "a\ufffdb", "a\ufffdb"},
+		{"cut off", "a\xed\xa0", "a\ufffd", "a\ufffd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := ModifiedUTF8.NewDecoder().String(tt.data); got != tt.mutf {
+				t.Errorf("ModifiedUTF8 decode = %q, want %q", got, tt.mutf)
+			}
+			if got, _ := CESU8.NewDecoder().String(tt.data); got != tt.cesu {
+				t.Errorf("CESU8 decode = %q, want %q", got, tt.cesu)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		mutf string
+		cesu string
+	}{
+		{"ASCII", "abc", "abc", "abc"},
+		{"NUL", "a\x00b", "a\xc0\x80b", "a\x00b"},
+		{"supplementary", "\U0001f4a9", "\xed\xa0\xbd\xed\xb2\xa9", "\xed\xa0\xbd\xed\xb2\xa9"},
+		{"invalid", "a\xffb", "a\xef\xbf\xbdb", "a\xef\xbf\xbdb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := ModifiedUTF8.NewEncoder().String(tt.str); got != tt.mutf {
+				t.Errorf("ModifiedUTF8 encode = %q, want %q", got, tt.mutf)
+			}
+			if got, _ := CESU8.NewEncoder().String(tt.str); got != tt.cesu {
+				t.Errorf("CESU8 encode = %q, want %q", got, tt.cesu)
+			}
+		})
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	data := "a\xc0\x80\xff\xed\xa0\xbdb"
+	tests := []struct {
+		policy jutf.OnError
+		mutf   string
+		cesu   string
+		err    error
+	}{
+		{jutf.Fail, "", "", encoding.ErrInvalidUTF8},
+		{jutf.Replace, "a\x00\ufffd\ufffdb", "a\ufffd\ufffd\ufffdb", nil},
+		{jutf.Skip, "a\x00b", "ab", nil},
+		{jutf.Passthrough, "a\x00\xff\xed\xa0\xbdb", "a\xc0\x80\xff\xed\xa0\xbdb", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			got, err := WithPolicy(ModifiedUTF8, tt.policy).NewDecoder().String(data)
+			if err != tt.err || err == nil && got != tt.mutf {
+				t.Errorf("ModifiedUTF8 decode = %q, %v; want %q, %v", got, err, tt.mutf, tt.err)
+			}
+			got, err = WithPolicy(CESU8, tt.policy).NewDecoder().String(data)
+			if err != tt.err || err == nil && got != tt.cesu {
+				t.Errorf("CESU8 decode = %q, %v; want %q, %v", got, err, tt.cesu, tt.err)
+			}
+		})
+	}
+
+	if got, _ := Name(WithPolicy(CESU8, jutf.Skip)); got != "CESU-8" {
+		t.Errorf("Name(WithPolicy(CESU8)) = %q", got)
+	}
+}
+
+// oneByte reads a byte at a time, so that sequences are split across
+// calls to Transform.
+type oneByte struct{ r io.Reader }
+
+func (o oneByte) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return o.r.Read(p)
+}
+
+func TestStream(t *testing.T) {
+	s := strings.Repeat("a\x00\u00e5\u65e5\U0001f4a9", 100)
+	var enc bytes.Buffer
+	w := transform.NewWriter(&enc, ModifiedUTF8.NewEncoder())
+	for i := 0; i < len(s); i++ {
+		w.Write([]byte{s[i]})
+	}
+	w.Close()
+
+	r := transform.NewReader(oneByte{&enc}, ModifiedUTF8.NewDecoder())
+	got, err := io.ReadAll(r)
+	if string(got) != s || err != nil {
+		t.Errorf("round trip = %.20q, %v; want %.20q", got, err, s)
+	}
+}
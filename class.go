@@ -0,0 +1,140 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+)
+
+var errClass = errors.New("not a class file")
+
+// ErrConstantPoolTag is reported, wrapped with its offset, for a constant
+// pool entry of unknown type in a class file.
+var ErrConstantPoolTag = errors.New("bad constant pool tag")
+
+// A classError is an error in a class file at a byte offset.
+type classError struct {
+	offset int
+	err    error
+}
+
+func (e *classError) Error() string {
+	return e.err.Error() + " at offset " + strconv.Itoa(e.offset)
+}
+
+func (e *classError) Unwrap() error {
+	return e.err
+}
+
+// cpSizes are the sizes of the constant pool entries other than
+// CONSTANT_Utf8, by tag.
+var cpSizes = [...]int{
+	3: 4, 4: 4, 5: 8, 6: 8, 7: 2, 8: 2, 9: 4, 10: 4, 11: 4, 12: 4,
+	15: 3, 16: 2, 17: 4, 18: 4, 19: 2, 20: 2,
+}
+
+// RewriteClassStrings returns a copy of the class file class in which each
+// CONSTANT_Utf8 entry holding a key of repl is replaced by the encoding of
+// its value, with the length updated. Everything else is copied unchanged,
+// so the constant pool indexes stay valid. Entries match by their encoding,
+// so only canonical ones do. If nothing matches, class itself is returned.
+func RewriteClassStrings(class []byte, repl map[string]string) ([]byte, error) {
+	enc := make(map[string][]byte, len(repl))
+	for old, s := range repl {
+		d := Encode(s)
+		if len(d) > maxUTF {
+			return nil, tooLongError(len(d))
+		}
+		enc[string(Encode(old))] = d
+	}
+
+	// out is only allocated on the first match; last is the end of what
+	// has been copied to it
+	var out []byte
+	last := 0
+	err := classUtf8(class, func(start, end int) {
+		d, ok := enc[string(class[start:end])]
+		if !ok {
+			return
+		}
+		if out == nil {
+			out = make([]byte, 0, len(class))
+		}
+		out = append(out, class[last:start-2]...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(d)))
+		out = append(out, d...)
+		last = end
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return class, nil
+	}
+	return append(out, class[last:]...), nil
+}
+
+// ClassStrings returns the data of the CONSTANT_Utf8 entries of the class
+// file class, in constant pool order, undecoded. They are subslices of
+// class.
+func ClassStrings(class []byte) ([][]byte, error) {
+	var strs [][]byte
+	err := classUtf8(class, func(start, end int) {
+		strs = append(strs, class[start:end:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return strs, nil
+}
+
+// classUtf8 calls fn with the offsets of the data of each CONSTANT_Utf8
+// entry of the class file class, in order.
+func classUtf8(class []byte, fn func(start, end int)) error {
+	if !bytes.HasPrefix(class, []byte{0xca, 0xfe, 0xba, 0xbe}) {
+		return errClass
+	}
+	if len(class) < 10 {
+		return io.ErrUnexpectedEOF
+	}
+	count := int(binary.BigEndian.Uint16(class[8:]))
+
+	i := 10
+	for k := 1; k < count; k++ {
+		if i >= len(class) {
+			return io.ErrUnexpectedEOF
+		}
+		tag := int(class[i])
+
+		switch {
+		case tag == 1:
+			if i+3 > len(class) {
+				return io.ErrUnexpectedEOF
+			}
+			n := int(binary.BigEndian.Uint16(class[i+1:]))
+			start := i + 3
+			if i = start + n; i > len(class) {
+				return io.ErrUnexpectedEOF
+			}
+			fn(start, i)
+		case tag < len(cpSizes) && cpSizes[tag] > 0:
+			i += 1 + cpSizes[tag]
+			if tag == 5 || tag == 6 {
+				// longs and doubles take two entries
+				k++
+			}
+		default:
+			return &classError{i, ErrConstantPoolTag}
+		}
+	}
+	if i > len(class) {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testClass returns the start of a class file with the given constant pool
+// entries, each a tag followed by the body, and a trailing access flags.
+func testClass(count int, entries ...[]byte) []byte {
+	b := []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52}
+	b = binary.BigEndian.AppendUint16(b, uint16(count))
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	return append(b, 0x00, 0x21)
+}
+
+// testUtf8Entry returns a CONSTANT_Utf8 entry holding d.
+func testUtf8Entry(d []byte) []byte {
+	b := binary.BigEndian.AppendUint16([]byte{1}, uint16(len(d)))
+	return append(b, d...)
+}
+
+func TestRewriteClassStrings(t *testing.T) {
+	class := testClass(7,
+		testUtf8Entry([]byte("com/example/Foo")),
+		[]byte{7, 0, 1},
+		[]byte{5, 1, 2, 3, 4, 5, 6, 7, 8}, // a long, two entries
+		testUtf8Entry([]byte("bar")),
+		testUtf8Entry(Encode("\x00")),
+	)
+	repl := map[string]string{
+		"com/example/Foo": "org/example/\U0001f4a9",
+		"\x00":            "",
+		"baz":             "nothing",
+	}
+	want := testClass(7,
+		testUtf8Entry(Encode("org/example/\U0001f4a9")),
+		[]byte{7, 0, 1},
+		[]byte{5, 1, 2, 3, 4, 5, 6, 7, 8},
+		testUtf8Entry([]byte("bar")),
+		testUtf8Entry(nil),
+	)
+	orig := bytes.Clone(class)
+
+	got, err := RewriteClassStrings(class, repl)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("RewriteClassStrings = %x, %v; want %x", got, err, want)
+	}
+	if !bytes.Equal(class, orig) {
+		t.Errorf("RewriteClassStrings modified its input")
+	}
+
+	if got, err := RewriteClassStrings(class, map[string]string{"baz": "x"}); err != nil || &got[0] != &class[0] {
+		t.Errorf("RewriteClassStrings(no match) = %x, %v; want the input", got, err)
+	}
+
+	if _, err := RewriteClassStrings(class, map[string]string{"bar": strings.Repeat("\x00", 40000)}); err == nil {
+		t.Errorf("RewriteClassStrings(too long) succeeded")
+	}
+
+	for _, cut := range [][]byte{class[:9], class[:12], class[:20], testClass(8)[:10]} {
+		if _, err := RewriteClassStrings(cut, repl); err != io.ErrUnexpectedEOF {
+			t.Errorf("RewriteClassStrings(%x) error = %v, want %v", cut, err, io.ErrUnexpectedEOF)
+		}
+	}
+
+	if _, err := RewriteClassStrings([]byte("PK\x03\x04"), repl); err != errClass {
+		t.Errorf("RewriteClassStrings(zip) error = %v, want %v", err, errClass)
+	}
+	_, err = RewriteClassStrings(testClass(2, []byte{2, 0, 0}), repl)
+	if !errors.Is(err, ErrConstantPoolTag) || err.Error() != "bad constant pool tag at offset 10" {
+		t.Errorf("RewriteClassStrings(bad tag) error = %v, want %v at offset 10", err, ErrConstantPoolTag)
+	}
+}
+
+func TestClassStrings(t *testing.T) {
+	class := testClass(6,
+		testUtf8Entry([]byte("com/example/Foo")),
+		[]byte{6, 1, 2, 3, 4, 5, 6, 7, 8}, // a double, two entries
+		testUtf8Entry(Encode("\x00")),
+		testUtf8Entry(nil),
+	)
+	want := [][]byte{[]byte("com/example/Foo"), {0xc0, 0x80}, {}}
+	got, err := ClassStrings(class)
+	if err != nil || !slices.EqualFunc(got, want, bytes.Equal) {
+		t.Errorf("ClassStrings = %q, %v; want %q", got, err, want)
+	}
+
+	if _, err := ClassStrings(class[:20]); err != io.ErrUnexpectedEOF {
+		t.Errorf("ClassStrings(cut off) error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
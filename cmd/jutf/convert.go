@@ -0,0 +1,52 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"flag"
+	"io"
+
+	"github.com/anders/jutf"
+)
+
+func encodeCmd(e *env, fs *flag.FlagSet, args []string) error {
+	rawNUL := fs.Bool("rawnul", false, "leave NUL as a single 0 byte")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var opts []jutf.Option
+	if *rawNUL {
+		opts = append(opts, jutf.RawNUL())
+	}
+
+	return e.each(fs.Args(), func(name string, r io.Reader) error {
+		enc := jutf.NewEncoder(e.stdout, opts...)
+		if _, err := io.Copy(enc, r); err != nil {
+			return err
+		}
+		return enc.Close()
+	})
+}
+
+func decodeCmd(e *env, fs *flag.FlagSet, args []string) error {
+	strict := fs.Bool("strict", false, "reject raw NULs and 4-byte sequences")
+	lossy := fs.Bool("lossy", false, "replace malformed input with U+FFFD")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var opts []jutf.Option
+	if *strict {
+		opts = append(opts, jutf.Strict())
+	}
+	if *lossy {
+		opts = append(opts, jutf.Lossy())
+	}
+
+	return e.each(fs.Args(), func(name string, r io.Reader) error {
+		_, err := io.Copy(e.stdout, jutf.NewDecoder(r, opts...))
+		return err
+	})
+}
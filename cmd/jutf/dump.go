@@ -0,0 +1,30 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/anders/jutf"
+)
+
+func dumpCmd(e *env, fs *flag.FlagSet, args []string) error {
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	return e.each(names, func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if len(names) > 1 {
+			fmt.Fprintf(e.stdout, "%s:\n", name)
+		}
+		return jutf.Dump(e.stdout, b)
+	})
+}
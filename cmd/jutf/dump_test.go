@@ -0,0 +1,33 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	in := "Hi\xc0\x80!\xed\xa0\xbd\xed\xb2\xa9 and \xff\x00 \xf0\x9f\x92\xa9 more"
+	want := []string{
+		"00000000  48 69 [c0 80] 21 [ed a0 bd ed b2 a9] 20 61 6e 64 20      |Hi..!...... and |  U+0000 U+1F4A9",
+		"00000010  [ff] [00] 20 [f0 9f 92 a9] 20 6d 6f 72 65                |.. .... more|      invalid raw-NUL 4-byte:U+1F4A9",
+		"",
+	}
+
+	code, out, stderr := runTest(t, in, "dump")
+	if code != 0 || out != strings.Join(want, "\n") {
+		t.Errorf("dump = %d, %s\nwant:\n%s\n%s", code, out, strings.Join(want, "\n"), stderr)
+	}
+}
+
+func TestDumpRows(t *testing.T) {
+	// the pair does not fit on the first row
+	in := strings.Repeat("a", 12) + "\xed\xa0\xbd\xed\xb2\xa9"
+	_, out, _ := runTest(t, in, "dump")
+	rows := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(rows) != 2 || !strings.HasPrefix(rows[1], "0000000c  [ed a0 bd ed b2 a9]") {
+		t.Errorf("dump =\n%s", out)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/anders/jutf"
+)
+
+func readUTFCmd(e *env, fs *flag.FlagSet, args []string) error {
+	quote := fs.Bool("quote", false, "print each string Go-quoted, so that newlines and NULs are visible")
+	max := fs.Int("max", 0, "reject frames longer than `n` bytes, if not 0")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	return e.each(fs.Args(), func(name string, r io.Reader) error {
+		br := bufio.NewReader(r)
+		for n := 0; ; n++ {
+			s, err := jutf.ReadUTF(br, jutf.MaxLen(*max))
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("frame %d: %w", n, err)
+			}
+
+			if *quote {
+				s = strconv.Quote(s)
+			}
+			e.stdout.WriteString(s)
+			e.stdout.WriteByte('\n')
+		}
+	})
+}
+
+func writeUTFCmd(e *env, fs *flag.FlagSet, args []string) error {
+	unquote := fs.Bool("unquote", false, "read each line as a Go-quoted string")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	return e.each(fs.Args(), func(name string, r io.Reader) error {
+		br := bufio.NewReader(r)
+		for n := 1; ; n++ {
+			line, err := br.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if line == "" && err == io.EOF {
+				return nil
+			}
+
+			s := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if *unquote {
+				var uerr error
+				if s, uerr = strconv.Unquote(s); uerr != nil {
+					return fmt.Errorf("line %d: %w", n, uerr)
+				}
+			}
+			if werr := jutf.WriteUTF(e.stdout, s); werr != nil {
+				return fmt.Errorf("line %d: %w", n, werr)
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteReadUTF(t *testing.T) {
+	in := "hello\r\nwörld\x00\n\n\U0001f4a9"
+	frames := "\x00\x05hello\x00\x08w\xc3\xb6rld\xc0\x80\x00\x00\x00\x06\xed\xa0\xbd\xed\xb2\xa9"
+
+	code, out, stderr := runTest(t, in, "writeutf")
+	if code != 0 || out != frames {
+		t.Errorf("writeutf = %d, %q; want %q (%s)", code, out, frames, stderr)
+	}
+
+	code, out, stderr = runTest(t, frames, "readutf")
+	if want := "hello\nwörld\x00\n\n\U0001f4a9\n"; code != 0 || out != want {
+		t.Errorf("readutf = %d, %q; want %q (%s)", code, out, want, stderr)
+	}
+
+	code, out, _ = runTest(t, frames, "readutf", "-quote")
+	if want := "\"hello\"\n\"wörld\\x00\"\n\"\"\n\"\U0001f4a9\"\n"; code != 0 || out != want {
+		t.Errorf("readutf -quote = %d, %q; want %q", code, out, want)
+	}
+}
+
+func TestQuotedRoundTrip(t *testing.T) {
+	in := "\"a\\nb\"\n\"\\x00\"\n"
+	_, frames, _ := runTest(t, in, "writeutf", "-unquote")
+	if code, out, _ := runTest(t, frames, "readutf", "-quote"); code != 0 || out != in {
+		t.Errorf("round trip = %d, %q; want %q", code, out, in)
+	}
+
+	if code, _, stderr := runTest(t, "a\"\n", "writeutf", "-unquote"); code != 1 || !strings.Contains(stderr, "line 1") {
+		t.Errorf("writeutf -unquote bad input = %d, %q", code, stderr)
+	}
+}
+
+func TestReadUTFErrors(t *testing.T) {
+	for _, in := range []string{"\x00\x05hello\x00", "\x00\x05hel", "\x00\x01\xff"} {
+		if code, _, stderr := runTest(t, in, "readutf"); code != 1 || !strings.Contains(stderr, "frame ") {
+			t.Errorf("readutf < %q = %d, %q; want error", in, code, stderr)
+		}
+	}
+
+	if code, _, stderr := runTest(t, "\x00\x02ab\x00\x05hello", "readutf", "-max", "4"); code != 1 || !strings.Contains(stderr, "frame 1: data too large") {
+		t.Errorf("readutf -max 4 = %d, %q; want error", code, stderr)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Command jutf converts between UTF-8 and the modified UTF-8 used by Java.
+//
+// Usage:
+//
+//	jutf encode [-rawnul] [file ...]
+//	jutf decode [-strict] [-lossy] [file ...]
+//	jutf validate [-q] [file ...]
+//	jutf dump [file ...]
+//	jutf readutf [-quote] [-max n] [file ...]
+//	jutf writeutf [-unquote] [file ...]
+//	jutf strings [-quote] file.class|classes.dex ...
+//
+// Input is read from the named files in turn, or from stdin if there are
+// none or a file is named "-". Output is written to stdout.
+//
+// Validate lists every malformed sequence with its offset and exits with
+// status 0 if the input is canonical modified UTF-8, 3 if it is valid but
+// has raw NULs or 4-byte sequences, which standard UTF-8 allows, and 4 if it
+// is malformed. Other errors exit with status 1, bad arguments with 2.
+//
+// Dump writes a hexdump with encoded NULs, surrogate pairs and malformed
+// sequences in brackets, and the code points they stand for at the end of
+// each row.
+//
+// Readutf reads the frames written by DataOutput.writeUTF, a 16-bit length
+// followed by modified UTF-8, and prints each as a line. Writeutf does the
+// opposite. With -quote and -unquote, the lines are Go string literals, so
+// that strings with newlines survive the round trip.
+//
+// Strings prints the string constants of .class and .dex files, that is the
+// CONSTANT_Utf8 entries of the constant pool and the string_data items, one
+// per line.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A command is a subcommand of jutf.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(e *env, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{
+	{"encode", "[-rawnul] [file ...]", "encode UTF-8 as modified UTF-8", encodeCmd},
+	{"decode", "[-strict] [-lossy] [file ...]", "decode modified UTF-8 to UTF-8", decodeCmd},
+	{"validate", "[-q] [file ...]", "report malformed sequences", validateCmd},
+	{"dump", "[file ...]", "hexdump with modified sequences annotated", dumpCmd},
+	{"readutf", "[-quote] [-max n] [file ...]", "print writeUTF frames as lines", readUTFCmd},
+	{"writeutf", "[-unquote] [file ...]", "write lines as writeUTF frames", writeUTFCmd},
+	{"strings", "[-quote] file.class|classes.dex ...", "print the strings of class and dex files", stringsCmd},
+}
+
+// env is where a command reads and writes.
+type env struct {
+	stdin  io.Reader
+	stdout *bufio.Writer
+	stderr io.Writer
+}
+
+// errUsage is returned for bad arguments, after the usage has been printed.
+var errUsage = errors.New("usage")
+
+// An exitCode is returned by a command to exit with that status, without
+// printing an error.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command line args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == args[0] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+			fmt.Fprintf(stderr, "jutf: unknown command %q\n", args[0])
+		}
+		usage(stderr)
+		return 2
+	}
+
+	e := &env{stdin: stdin, stdout: bufio.NewWriter(stdout), stderr: stderr}
+	err := cmd.run(e, cmd.flags(stderr), args[1:])
+	if ferr := e.stdout.Flush(); err == nil {
+		err = ferr
+	}
+
+	var code exitCode
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.As(err, &code):
+		return int(code)
+	}
+	fmt.Fprintf(stderr, "jutf: %v\n", err)
+	return 1
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: jutf <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.short)
+	}
+}
+
+// flags returns a FlagSet for c, printing errors and usage to w.
+func (c *command) flags(w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() {
+		fmt.Fprintf(w, "usage: jutf %s %s\n", c.name, c.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args with fs, returning errUsage if they are bad.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// each calls fn with each input named by names, or stdin if there are none.
+// Errors from fn are prefixed with the name of the file.
+func (e *env) each(names []string, fn func(name string, r io.Reader) error) error {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	for _, name := range names {
+		if name == "-" {
+			if err := fn("<stdin>", e.stdin); err != nil {
+				return prefixed("<stdin>", err)
+			}
+			continue
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = fn(name, f)
+		f.Close()
+		if err != nil {
+			return prefixed(name, err)
+		}
+	}
+	return nil
+}
+
+// prefixed prefixes err with name, unless it is an exitCode.
+func prefixed(name string, err error) error {
+	var code exitCode
+	if errors.As(err, &code) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
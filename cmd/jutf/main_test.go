@@ -0,0 +1,73 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runTest runs args with stdin and returns the exit status and output.
+func runTest(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"bogus"}, {"help"}, {"encode", "-bogus"}} {
+		if code, _, stderr := runTest(t, "", args...); code != 2 || !strings.Contains(stderr, "usage: jutf") {
+			t.Errorf("jutf %q = %d, %q; want usage", args, code, stderr)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		args []string
+		in   string
+		out  string
+		code int
+	}{
+		{[]string{"encode"}, "a\x00b\U0001f4a9", "a\xc0\x80b\xed\xa0\xbd\xed\xb2\xa9", 0},
+		{[]string{"encode", "-rawnul"}, "a\x00b", "a\x00b", 0},
+		{[]string{"decode"}, "a\xc0\x80b\xed\xa0\xbd\xed\xb2\xa9", "a\x00b\U0001f4a9", 0},
+		{[]string{"decode"}, "a\x00b", "a\x00b", 0},
+		{[]string{"decode", "-strict"}, "a\x00b", "a", 1},
+		{[]string{"decode"}, "a\xffb", "a", 1},
+		{[]string{"decode", "-lossy"}, "a\xffb", "a�b", 0},
+	}
+	for _, tt := range tests {
+		code, out, stderr := runTest(t, tt.in, tt.args...)
+		if code != tt.code || out != tt.out {
+			t.Errorf("jutf %q < %q = %d, %q; want %d, %q (%s)", tt.args, tt.in, code, out, tt.code, tt.out, stderr)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("a\xc0\x80"), 0o666)
+	os.WriteFile(b, []byte("b\xff"), 0o666)
+
+	code, out, _ := runTest(t, "-\xc0\x80", "decode", a, "-", a)
+	if want := "a\x00-\x00a\x00"; code != 0 || out != want {
+		t.Errorf("decode a - a = %d, %q; want 0, %q", code, out, want)
+	}
+
+	code, _, stderr := runTest(t, "", "decode", a, b)
+	if code != 1 || !strings.HasPrefix(stderr, "jutf: "+b+": ") {
+		t.Errorf("decode a b = %d, %q; want error for %s", code, stderr, b)
+	}
+
+	if code, _, _ := runTest(t, "", "encode", filepath.Join(dir, "missing")); code != 1 {
+		t.Errorf("encode missing = %d, want 1", code)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"strconv"
+
+	"github.com/anders/jutf"
+)
+
+var errFormat = errors.New("not a class or dex file")
+
+func stringsCmd(e *env, fs *flag.FlagSet, args []string) error {
+	quote := fs.Bool("quote", false, "print each string Go-quoted")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	return e.each(fs.Args(), func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		var strs []string
+		switch {
+		case bytes.HasPrefix(b, []byte{0xca, 0xfe, 0xba, 0xbe}):
+			var ds [][]byte
+			ds, err = jutf.ClassStrings(b)
+			for _, d := range ds {
+				s, _ := jutf.Decode(d, jutf.Lossy())
+				strs = append(strs, s)
+			}
+		case bytes.HasPrefix(b, []byte("dex\n")):
+			strs, err = jutf.ParseDexStrings(bytes.NewReader(b))
+		default:
+			err = errFormat
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, s := range strs {
+			if *quote {
+				s = strconv.Quote(s)
+			}
+			e.stdout.WriteString(s)
+			e.stdout.WriteByte('\n')
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testClass is the start of a class file, up to the end of its constant
+// pool, with the strings "Foo" and "a\x00\U0001f4a9" along with a long and
+// a class entry.
+var testClass = "\xca\xfe\xba\xbe\x00\x00\x00\x34\x00\x06" +
+	"\x01\x00\x03Foo" +
+	"\x05\x00\x00\x00\x00\x00\x00\x00\x01" +
+	"\x07\x00\x01" +
+	"\x01\x00\x09a\xc0\x80\xed\xa0\xbd\xed\xb2\xa9"
+
+// testDex returns a dex file with only a header and the strings ss.
+func testDex(ss ...string) string {
+	b := make([]byte, 0x70)
+	copy(b, "dex\n035\x00")
+	binary.LittleEndian.PutUint32(b[56:], uint32(len(ss)))
+	binary.LittleEndian.PutUint32(b[60:], 0x70)
+
+	ids := make([]byte, 4*len(ss))
+	var data []byte
+	for k, s := range ss {
+		binary.LittleEndian.PutUint32(ids[4*k:], uint32(0x70+len(ids)+len(data)))
+		data = append(data, byte(len(s)))
+		data = append(data, s...)
+		data = append(data, 0)
+	}
+	return string(b) + string(ids) + string(data)
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"class", testClass, "\"Foo\"\n\"a\\x00\U0001f4a9\"\n"},
+		{"dex", testDex("Foo", "a\xc0\x80\xed\xa0\xbd\xed\xb2\xa9"), "\"Foo\"\n\"a\\x00\U0001f4a9\"\n"},
+	}
+	for _, tt := range tests {
+		code, out, stderr := runTest(t, tt.in, "strings", "-quote")
+		if code != 0 || out != tt.want {
+			t.Errorf("strings %s = %d, %q; want %q (%s)", tt.name, code, out, tt.want, stderr)
+		}
+	}
+}
+
+func TestStringsErrors(t *testing.T) {
+	for _, in := range []string{"", "hello", testClass[:20], testClass[:10] + "\x63", testDex("a")[:0x76]} {
+		if code, _, _ := runTest(t, in, "strings"); code != 1 {
+			t.Errorf("strings < %q = %d, want 1", in, code)
+		}
+	}
+}
+
+func TestStringsFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "A.class")
+	os.WriteFile(a, []byte(testClass), 0o666)
+
+	code, out, _ := runTest(t, "", "strings", a, a)
+	if want := "Foo\na\x00\U0001f4a9\n"; code != 0 || out != want+want {
+		t.Errorf("strings a a = %d, %q", code, out)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/anders/jutf"
+)
+
+// Exit statuses of validate, from best to worst.
+const (
+	exitValid    = 0 // canonical modified UTF-8
+	exitStandard = 3 // valid, but with raw NULs or 4-byte sequences
+	exitInvalid  = 4 // malformed
+)
+
+// number of bytes shown on either side of a problem.
+const snippetLen = 4
+
+func validateCmd(e *env, fs *flag.FlagSet, args []string) error {
+	quiet := fs.Bool("q", false, "only set the exit status")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	status := exitValid
+	err := e.each(fs.Args(), func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range jutf.ValidateAll(b) {
+			if !*quiet {
+				fmt.Fprintf(e.stdout, "%s:%d: %v: %s\n", name, p.Offset, p.Err, snippet(b, p.Offset, p.Len))
+			}
+
+			s := exitInvalid
+			if errors.Is(p.Err, jutf.ErrInvalidNUL) || errors.Is(p.Err, jutf.ErrFourByte) {
+				s = exitStandard
+			}
+			if s > status {
+				status = s
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if status != exitValid {
+		return exitCode(status)
+	}
+	return nil
+}
+
+// snippet returns b[off:off+n] in hex, in brackets, along with a few bytes
+// on either side.
+func snippet(b []byte, off, n int) string {
+	start := max(off-snippetLen, 0)
+	end := min(off+n+snippetLen, len(b))
+
+	s := ""
+	if start > 0 {
+		s = "... "
+	}
+	s += fmt.Sprintf("% x", b[start:off])
+	if start < off {
+		s += " "
+	}
+	s += fmt.Sprintf("[% x]", b[off:off+n])
+	if off+n < end {
+		s += fmt.Sprintf(" % x", b[off+n:end])
+	}
+	if end < len(b) {
+		s += " ..."
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package main
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in   string
+		code int
+		out  string
+	}{
+		{"", exitValid, ""},
+		{"a\xc0\x80b\xed\xa0\xbd\xed\xb2\xa9", exitValid, ""},
+		{"a\x00b", exitStandard, "<stdin>:1: short NUL codepoint not allowed: 61 [00] 62\n"},
+		{"0123456789\xff", exitInvalid, "<stdin>:10: invalid encoding: ... 36 37 38 39 [ff]\n"},
+		{
+			"\U0001f4a9 \xed\xa0\x80.",
+			exitInvalid,
+			"<stdin>:0: 4-byte sequence not allowed: [f0 9f 92 a9] 20 ed a0 80 ...\n" +
+				"<stdin>:5: unpaired surrogate: ... 9f 92 a9 20 [ed a0 80] 2e\n",
+		},
+	}
+	for _, tt := range tests {
+		code, out, stderr := runTest(t, tt.in, "validate")
+		if code != tt.code || out != tt.out {
+			t.Errorf("validate < %q = %d, %q; want %d, %q (%s)", tt.in, code, out, tt.code, tt.out, stderr)
+		}
+		if code, out, _ := runTest(t, tt.in, "validate", "-q"); code != tt.code || out != "" {
+			t.Errorf("validate -q < %q = %d, %q; want %d", tt.in, code, out, tt.code)
+		}
+	}
+}
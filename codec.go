@@ -0,0 +1,56 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+// A Codec encodes and decodes like the package-level functions, but reuses an
+// internal buffer between calls. The zero value is ready to use with default
+// options. A Codec must not be used concurrently.
+type Codec struct {
+	o   options
+	buf []byte
+}
+
+// NewCodec returns a Codec using the given options.
+func NewCodec(opts ...Option) *Codec {
+	return &Codec{o: newOptions(opts)}
+}
+
+// Encode is like the package-level Encode. The result is only valid until
+// the next call to c.
+func (c *Codec) Encode(s string) []byte {
+	c.buf = encode(c.buf[:0], s, &c.o)
+	return c.buf
+}
+
+// Decode is like the package-level Decode. Only the returned string is
+// allocated.
+func (c *Codec) Decode(d []byte) (string, error) {
+	b, err := c.DecodeBytes(d)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodeBytes is like DecodeToBytes. The result is only valid until the next
+// call to c.
+func (c *Codec) DecodeBytes(d []byte) ([]byte, error) {
+	if err := c.o.checkLimits(d); err != nil {
+		return nil, err
+	}
+
+	start := c.o.bomLen(d)
+	i := same(d, start, len(d), &c.o)
+	buf, err := decode(append(c.buf[:0], d[start:i]...), d, i, len(d), &c.o)
+	c.buf = buf
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Reset releases the internal buffer, for instance after a large input.
+func (c *Codec) Reset() {
+	c.buf = nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	var c Codec
+	for _, s := range []string{"", "ASCII", "a\x00b", "åäö 日本語 \U0001f4a9"} {
+		if got := c.Encode(s); !bytes.Equal(got, Encode(s)) {
+			t.Errorf("Encode(%q) = %x, want %x", s, got, Encode(s))
+		}
+		if got, err := c.Decode(Encode(s)); err != nil || got != s {
+			t.Errorf("Decode() = %q, %v; want %q", got, err, s)
+		}
+	}
+
+	if _, err := NewCodec(Strict()).Decode([]byte("a\x00")); err == nil {
+		t.Errorf("Decode() with Strict accepted a raw NUL")
+	}
+
+	c.Reset()
+	s := "Hello\x00Wörld!!! \U0001f4a9"
+	d := Encode(s)
+	c.Encode(s)
+	if n := testing.AllocsPerRun(100, func() { c.Encode(s) }); n != 0 {
+		t.Errorf("Encode() allocates %v times", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { c.DecodeBytes(d) }); n != 0 {
+		t.Errorf("DecodeBytes() allocates %v times", n)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Compare orders encoded strings by UTF-16 char, like String.compareTo in
+// Java, returning -1, 0 or +1. This differs from the order of code points
+// where supplementary characters are compared with U+E000 to U+FFFF. Raw
+// NULs and 4-byte sequences are compared as the chars they stand for, and
+// other malformed sequences as U+FFFD.
+func Compare(a, b []byte) int {
+	// skip the common prefix, back to the start of a sequence
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	for i > 0 && (i < len(a) && a[i]&0xc0 == 0x80 || i < len(b) && b[i]&0xc0 == 0x80) {
+		i--
+	}
+
+	ra, rb := charReader{d: a, i: i}, charReader{d: b, i: i}
+	for {
+		ca, oka := ra.next()
+		cb, okb := rb.next()
+		switch {
+		case !oka && !okb:
+			return 0
+		case !oka:
+			return -1
+		case !okb:
+			return +1
+		case ca < cb:
+			return -1
+		case ca > cb:
+			return +1
+		}
+	}
+}
+
+// A charReader returns the UTF-16 chars of encoded data one at a time.
+type charReader struct {
+	d   []byte
+	i   int
+	low uint16 // second half of a 4-byte sequence, if not 0
+	bad []byte // the malformed sequence last returned as U+FFFD
+}
+
+func (r *charReader) next() (uint16, bool) {
+	r.bad = nil
+	if r.low != 0 {
+		c := r.low
+		r.low = 0
+		return c, true
+	}
+	if r.i == len(r.d) {
+		return 0, false
+	}
+
+	d := r.d[r.i:]
+	if d[0] < 0x80 {
+		r.i++
+		return uint16(d[0]), true
+	}
+
+	n, err := scan(d)
+	switch {
+	case err == nil && n == 2:
+		r.i += 2
+		return uint16(d[0]&0x1f)<<6 | uint16(d[1]&0x3f), true
+	case err == nil, err == ErrUnpairedSurrogate, err == ErrTooShortSurrogate && n >= 3:
+		// 3 bytes, or one half of a pair
+		r.i += 3
+		return uint16(d[0]&0x0f)<<12 | uint16(d[1]&0x3f)<<6 | uint16(d[2]&0x3f), true
+	case err == ErrFourByte:
+		r.i += 4
+		c, _ := utf8.DecodeRune(d)
+		hi, lo := EncodeSurrogatePair(c)
+		r.low = lo
+		return hi, true
+	}
+	r.bad = d[:n]
+	r.i += n
+	return utf8.RuneError, true
+}
+
+// Equal reports whether a and b decode to the same string, even if one of
+// them uses raw NULs or 4-byte sequences and the other the modified forms.
+// Malformed sequences are only equal to the same bytes.
+func Equal(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	ra, rb := charReader{d: a}, charReader{d: b}
+	for {
+		ca, oka := ra.next()
+		cb, okb := rb.next()
+		switch {
+		case oka != okb || ca != cb:
+			return false
+		case !oka:
+			return true
+		case (ra.bad != nil || rb.bad != nil) && !bytes.Equal(ra.bad, rb.bad):
+			return false
+		}
+	}
+}
+
+// EqualString reports whether b decodes to s, the same as comparing the
+// result of Decode with s, but without decoding b.
+func EqualString(b []byte, s string) bool {
+	i, j, std, mod := match(b, s)
+
+	// Decode fails for a mix of forms
+	return i == len(b) && j == len(s) && !(std && mod)
+}
+
+// EqualFold reports whether b decodes to a string equal to s under simple
+// Unicode case folding, like strings.EqualFold, without decoding b. Raw NULs
+// and 4-byte sequences are accepted; other malformed input in b is not equal
+// to anything.
+func EqualFold(b []byte, s string) bool {
+	i, j := 0, 0
+	for i < len(b) && j < len(s) {
+		var br, sr rune
+		if b[i] < utf8.RuneSelf {
+			br = rune(b[i])
+			i++
+		} else {
+			var n int
+			br, n = DecodeRune(b[i:])
+			if br == utf8.RuneError {
+				if _, err := scan(b[i:]); err != nil && err != ErrFourByte {
+					return false
+				}
+			}
+			i += n
+		}
+		if s[j] < utf8.RuneSelf {
+			sr = rune(s[j])
+			j++
+		} else {
+			var n int
+			sr, n = utf8.DecodeRuneInString(s[j:])
+			j += n
+		}
+
+		if br == sr {
+			continue
+		}
+		if br < sr {
+			br, sr = sr, br
+		}
+		// fast check for ASCII
+		if br < utf8.RuneSelf {
+			if 'A' <= sr && sr <= 'Z' && br == sr+'a'-'A' {
+				continue
+			}
+			return false
+		}
+
+		// the fold orbit of sr, from the smallest, until it reaches br
+		r := unicode.SimpleFold(sr)
+		for r != sr && r < br {
+			r = unicode.SimpleFold(r)
+		}
+		if r != br {
+			return false
+		}
+	}
+	return i == len(b) && j == len(s)
+}
+
+// match walks b and s in lockstep for as long as they decode the same,
+// returning how far it got in each and whether standard and modified forms
+// were seen in b. It stops at sequence boundaries in b, and at malformed
+// input.
+func match(b []byte, s string) (i, j int, std, mod bool) {
+	for i < len(b) && j < len(s) {
+		end := len(b)
+		if end-i > len(s)-j {
+			end = i + len(s) - j
+		}
+		if span := asciiSpan(b[i:end]); span > 0 {
+			if string(b[i:i+span]) != s[j:j+span] {
+				// not worth finding where exactly
+				return i, j, std, mod
+			}
+			i, j = i+span, j+span
+			continue
+		}
+
+		n, err := scan(b[i:])
+		switch {
+		case err == nil && b[i] == 0xc0:
+			if s[j] != 0 {
+				return i, j, std, mod
+			}
+			j++
+			mod = true
+		case err == nil && n == 6:
+			if r, size := utf8.DecodeRuneInString(s[j:]); size != 4 || r != decodePair(b[i:]) {
+				return i, j, std, mod
+			}
+			j += 4
+			mod = true
+		case err == nil || err == ErrInvalidNUL || err == ErrFourByte:
+			if n > len(s)-j || string(b[i:i+n]) != s[j:j+n] {
+				return i, j, std, mod
+			}
+			j += n
+			std = std || err != nil
+		default:
+			return i, j, std, mod
+		}
+		i += n
+	}
+	return i, j, std, mod
+}
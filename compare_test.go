@@ -0,0 +1,168 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+// compareJava is String.compareTo, reduced to its sign.
+func compareJava(a, b string) int {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			if ua[i] < ub[i] {
+				return -1
+			}
+			return +1
+		}
+	}
+	switch {
+	case len(ua) < len(ub):
+		return -1
+	case len(ua) > len(ub):
+		return +1
+	}
+	return 0
+}
+
+func TestCompare(t *testing.T) {
+	strs := []string{
+		"", "\x00", "\x00a", "a", "a\x00", "ab", "abc", "b", "é", "ée",
+		"\uffff", "\ue000", "\U0001f4a9", "\U0001f4a9a", "\U00010000", "\U0010ffff",
+		"a\U0001f4a9", "a\uffff", "a\ue000",
+	}
+	for _, a := range strs {
+		for _, b := range strs {
+			want := compareJava(a, b)
+			if got := Compare(Encode(a), Encode(b)); got != want {
+				t.Errorf("Compare(%q, %q) = %d, want %d", a, b, got, want)
+			}
+
+			// standard UTF-8 forms compare the same
+			if got := Compare([]byte(a), Encode(b)); got != want {
+				t.Errorf("Compare(%q as UTF-8, %q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestCompareMalformed(t *testing.T) {
+	tests := []struct {
+		a, b []byte
+		want int
+	}{
+		{[]byte{'a', 0xff}, []byte("a\ufffd"), 0},
+		{[]byte{'a', 0xff}, []byte("a\ufffe"), -1},
+		{[]byte{'a', 0xc3}, []byte{'a', 0xc3, 0xa9}, +1},
+		{[]byte{0xed, 0xa0, 0xbd}, Encode("\U0001f4a9"), -1},
+		{[]byte{0xed, 0xb2, 0xa9}, Encode("\uffff"), -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEqualString(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		s    string
+		want bool
+	}{
+		{nil, "", true},
+		{[]byte("abc"), "abc", true},
+		{[]byte("abc"), "abcd", false},
+		{[]byte("abcd"), "abc", false},
+		{Encode("a\x00b"), "a\x00b", true},
+		{Encode("a\x00b"), "a\x01b", false},
+		{Encode("\U0001f4a9"), "\U0001f4a9", true},
+		{Encode("\U0001f4a9"), "\U0001f4aa", false},
+		{Encode("åこ\U0001f4a9\x00"), "åこ\U0001f4a9\x00", true},
+		{[]byte("a\x00\U0001f4a9"), "a\x00\U0001f4a9", true},
+		{[]byte{0, 0xc0, 0x80}, "\x00\x00", false},
+		{[]byte{0xc0, 0x80}, "\xc0\x80", false},
+		{[]byte{0xff}, "\xff", false},
+		{[]byte{0xed, 0xa0, 0xbd}, "\xed\xa0\xbd", false},
+	}
+	for _, tt := range tests {
+		if got := EqualString(tt.b, tt.s); got != tt.want {
+			t.Errorf("EqualString(%x, %q) = %v, want %v", tt.b, tt.s, got, tt.want)
+		}
+
+		// the same as decoding
+		s, err := Decode(tt.b)
+		if want := err == nil && s == tt.s; want != tt.want {
+			t.Errorf("Decode(%x) = %q, %v", tt.b, s, err)
+		}
+	}
+}
+
+func TestEqualStringAllocs(t *testing.T) {
+	b := Encode("java/lang/\x00\U0001f4a9")
+	if n := testing.AllocsPerRun(10, func() { EqualString(b, "java/lang/\x00\U0001f4a9") }); n != 0 {
+		t.Errorf("EqualString allocates %v times", n)
+	}
+}
+
+func TestEqualFold(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		s    string
+		want bool
+	}{
+		{nil, "", true},
+		{[]byte("Java/Lang"), "jAVA/lANG", true},
+		{[]byte("abc"), "abd", false},
+		{[]byte("abc"), "ab", false},
+		{[]byte("ab"), "abc", false},
+		{[]byte("a@"), "A`", false},
+		{Encode("Straße\x00"), "STRAßE\x00", true},
+		{Encode("ÅNGSTRÖM"), "ångström", true},
+		{Encode("\u212a"), "k", true}, // Kelvin sign
+		{Encode("σ"), "ς", true},
+		{Encode("\U00010400"), "\U00010428", true}, // Deseret
+		{[]byte("\U00010400"), "\U00010428", true},
+		{Encode("\U00010400"), "\U00010429", false},
+		{[]byte("\x00"), "\x00", true},
+		{[]byte("\ufffd"), "\ufffd", true},
+		{[]byte{0xff}, "\ufffd", false},
+		{[]byte{0xed, 0xa0, 0xbd}, "\ufffd", false},
+	}
+	for _, tt := range tests {
+		if got := EqualFold(tt.b, tt.s); got != tt.want {
+			t.Errorf("EqualFold(%x, %q) = %v, want %v", tt.b, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b []byte
+		want bool
+	}{
+		{nil, []byte{}, true},
+		{[]byte("abc"), []byte("abc"), true},
+		{[]byte("abc"), []byte("abd"), false},
+		{[]byte("abc"), []byte("ab"), false},
+		{[]byte("a\x00\U0001f4a9"), Encode("a\x00\U0001f4a9"), true},
+		{[]byte{0, 0xc0, 0x80}, []byte{0xc0, 0x80, 0}, true},
+		{[]byte("\U0001f4a9x"), append(Encode("\U0001f4a9"), 'x'), true},
+		{[]byte("\U0001f4a9"), Encode("\U0001f4aa"), false},
+		{[]byte{'a', 0xff}, []byte{'a', 0xff}, true},
+		{[]byte{'a', 0xff, 0}, []byte{'a', 0xff, 0xc0, 0x80}, true},
+		{[]byte{'a', 0xff}, []byte{'a', 0xfe}, false},
+		{[]byte{'a', 0xff}, []byte("a\ufffd"), false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%x, %x) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := Equal(tt.b, tt.a); got != tt.want {
+			t.Errorf("Equal(%x, %x) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "unicode/utf8"
+
+// Canonicalize rewrites raw NUL bytes and 4-byte UTF-8 sequences in b to
+// their modified forms and reports whether anything changed. Other malformed
+// input is copied unchanged. If nothing changed b itself is returned.
+func Canonicalize(b []byte) ([]byte, bool) {
+	var out []byte
+	last := 0 // start of the bytes not yet copied to out
+
+	for i := 0; i < len(b); {
+		n, err := scan(b[i:])
+		if err != ErrInvalidNUL && err != ErrFourByte {
+			i += n
+			continue
+		}
+
+		if out == nil {
+			out = make([]byte, 0, len(b)+len(b)/2)
+		}
+		out = append(out, b[last:i]...)
+
+		if err == ErrInvalidNUL {
+			out = appendRune(out, 0)
+		} else {
+			r, _ := utf8.DecodeRune(b[i:])
+			out = appendRune(out, r)
+		}
+
+		i += n
+		last = i
+	}
+
+	if out == nil {
+		return b, false
+	}
+	return append(out, b[last:]...), true
+}
+
+// ToStandardUTF8 converts b to standard UTF-8 without going through a
+// string. Like Decode, input that already is valid UTF-8 is copied as is.
+// Errors are of type *DecodeError.
+func ToStandardUTF8(b []byte) ([]byte, error) {
+	return DecodeToBytes(b)
+}
+
+// FromStandardUTF8 converts the UTF-8 in b to modified UTF-8. It is the
+// same as EncodeBytes. Invalid UTF-8 becomes U+FFFD.
+func FromStandardUTF8(b []byte) []byte {
+	return EncodeBytes(b)
+}
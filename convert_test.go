@@ -0,0 +1,61 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		changed bool
+	}{
+		{"empty", []byte{}, []byte{}, false},
+		{"canonical", []byte{'a', 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, []byte{'a', 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, false},
+		{"raw NUL", []byte("a\x00b"), []byte{'a', 0xc0, 0x80, 'b'}, true},
+		{"four byte", []byte("\U0001f4a9\x00"), []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9, 0xc0, 0x80}, true},
+		{"garbage kept", []byte{0xff, 0}, []byte{0xff, 0xc0, 0x80}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Canonicalize(tt.data)
+			if !bytes.Equal(got, tt.want) || changed != tt.changed {
+				t.Errorf("Canonicalize() = %x, %v; want %x, %v", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestToStandardUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+		err  bool
+	}{
+		{"UTF-8", []byte("a\x00\U0001f4a9"), []byte("a\x00\U0001f4a9"), false},
+		{"modified", []byte{'a', 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, []byte("a\x00\U0001f4a9"), false},
+		{"invalid", []byte{'a', 0xc0, 0x80, 0xff}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToStandardUTF8(tt.data)
+			if (err != nil) != tt.err || err == nil && !bytes.Equal(got, tt.want) {
+				t.Errorf("ToStandardUTF8() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromStandardUTF8(t *testing.T) {
+	for _, s := range []string{"", "ASCII", "a\x00b", "åäö 日本語 \U0001f4a9", "bad \xff\xed\xa0\xbd"} {
+		if got, want := FromStandardUTF8([]byte(s)), Encode(s); !bytes.Equal(got, want) {
+			t.Errorf("FromStandardUTF8(%q) = %x, want %x", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errTrailingData = errors.New("trailing data after string")
+
+// Data is encoded data, which formats legibly: the %s and %v verbs print it
+// decoded, %q quotes the decoding, and %x and %X print the raw bytes in hex.
+// Malformed sequences are printed as U+FFFD.
+type Data []byte
+
+// String returns d decoded, with malformed sequences replaced by U+FFFD.
+func (d Data) String() string {
+	s, _ := Decode(d, Lossy())
+	return s
+}
+
+// Format implements fmt.Formatter. Flags, width and precision apply as they
+// would to a string, or to a byte slice for %x and %X.
+func (d Data) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v', 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), d.String())
+	case 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), []byte(d))
+	default:
+		fmt.Fprintf(f, "%%!%c(jutf.Data=%s)", verb, d.String())
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, returning d decoded.
+// Malformed data is an error.
+func (d Data) MarshalText() ([]byte, error) {
+	return DecodeToBytes(d)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, setting d to the
+// encoding of text.
+func (d *Data) UnmarshalText(text []byte) error {
+	*d = EncodeBytes(text)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, returning d framed as
+// by DataOutput.writeUTF: a big-endian 16-bit length followed by d. Data
+// longer than 65535 bytes is a *JavaError, as Java throws.
+func (d Data) MarshalBinary() ([]byte, error) {
+	if len(d) > maxUTF {
+		return nil, tooLongError(len(d))
+	}
+
+	b := make([]byte, 2, 2+len(d))
+	binary.BigEndian.PutUint16(b, uint16(len(d)))
+	return append(b, d...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, setting d to a copy
+// of the data framed as by DataOutput.writeUTF. The data is not checked.
+// Missing data is a *JavaError for EOFException, as from ReadUTF.
+func (d *Data) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		if len(b) == 0 {
+			return eofError(io.EOF)
+		}
+		return eofError(io.ErrUnexpectedEOF)
+	}
+
+	switch n := int(binary.BigEndian.Uint16(b)); {
+	case len(b)-2 < n:
+		return eofError(io.ErrUnexpectedEOF)
+	case len(b)-2 > n:
+		return errTrailingData
+	}
+	*d = append(Data{}, b[2:]...)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, returning d decoded as a JSON
+// string, or null if d is nil. Malformed data is an error.
+func (d Data) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("null"), nil
+	}
+
+	s, err := Decode(d)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, setting d to the encoding of a
+// JSON string, or to nil for null.
+func (d *Data) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*d = nil
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*d = Encode(s)
+	return nil
+}
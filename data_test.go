@@ -0,0 +1,124 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDataFormat(t *testing.T) {
+	d := Data(Encode("a\x00é"))
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%s", "a\x00é"},
+		{"%v", "a\x00é"},
+		{"%q", `"a\x00é"`},
+		{"%+q", `"a\x00\u00e9"`},
+		{"%x", "61c080c3a9"},
+		{"% X", "61 C0 80 C3 A9"},
+		{"%6s", "   a\x00é"},
+		{"%d", "%!d(jutf.Data=a\x00é)"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, d); got != tt.want {
+			t.Errorf("Sprintf(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	if got := fmt.Sprint(Data{'a', 0xff}); got != "a�" {
+		t.Errorf("Sprint(malformed) = %q", got)
+	}
+}
+
+func TestDataText(t *testing.T) {
+	var _ encoding.TextMarshaler = Data(nil)
+	var _ encoding.TextUnmarshaler = (*Data)(nil)
+
+	d := Data(Encode("a\x00\U0001f4a9"))
+	text, err := d.MarshalText()
+	if err != nil || string(text) != "a\x00\U0001f4a9" {
+		t.Errorf("MarshalText() = %q, %v", text, err)
+	}
+
+	var back Data
+	if err := back.UnmarshalText(text); err != nil || !bytes.Equal(back, d) {
+		t.Errorf("UnmarshalText(%q) = %x, %v; want %x", text, back, err, d)
+	}
+
+	if _, err := (Data{0xff}).MarshalText(); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("MarshalText(ff) error = %v", err)
+	}
+}
+
+func TestDataBinary(t *testing.T) {
+	var _ encoding.BinaryMarshaler = Data(nil)
+	var _ encoding.BinaryUnmarshaler = (*Data)(nil)
+
+	d := Data(Encode("a\x00b"))
+	b, err := d.MarshalBinary()
+	if err != nil || string(b) != "\x00\x04a\xc0\x80b" {
+		t.Fatalf("MarshalBinary() = %q, %v", b, err)
+	}
+
+	var back Data
+	if err := back.UnmarshalBinary(b); err != nil || !bytes.Equal(back, d) {
+		t.Errorf("UnmarshalBinary(%q) = %x, %v", b, back, err)
+	}
+
+	var je *JavaError
+	if _, err := make(Data, 1<<16).MarshalBinary(); !errors.As(err, &je) || je.Exception != UTFDataFormatException {
+		t.Errorf("MarshalBinary(too long) error = %v", err)
+	}
+	for _, bad := range [][]byte{{}, {0}, {0, 2, 'a'}, {0, 1, 'a', 'b'}} {
+		if err := back.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestDataGob(t *testing.T) {
+	var buf bytes.Buffer
+	in := struct{ Name Data }{Data(Encode("\U0001f4a9"))}
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct{ Name Data }
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil || !bytes.Equal(out.Name, in.Name) {
+		t.Errorf("gob round trip = %x, %v", out.Name, err)
+	}
+}
+
+func TestDataJSON(t *testing.T) {
+	type response struct {
+		Name  Data
+		Empty Data
+	}
+	in := response{Name: Data(Encode("a\x00<\U0001f4a9"))}
+
+	b, err := json.Marshal(in)
+	if want := `{"Name":"a\u0000\u003c` + "\U0001f4a9" + `","Empty":null}`; err != nil || string(b) != want {
+		t.Fatalf("Marshal() = %s, %v; want %s", b, err, want)
+	}
+
+	var out response
+	if err := json.Unmarshal(b, &out); err != nil || !bytes.Equal(out.Name, in.Name) || out.Empty != nil {
+		t.Errorf("Unmarshal(%s) = %+v, %v", b, out, err)
+	}
+
+	if _, err := json.Marshal(Data{0xff}); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("Marshal(ff) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"Name":1}`), &out); err == nil {
+		t.Errorf("Unmarshal(number) succeeded")
+	}
+}
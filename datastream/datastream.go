@@ -0,0 +1,295 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package datastream reads and writes the binary formats of Java's
+// java.io.DataInput and DataOutput: big-endian integers and floats, and
+// strings in modified UTF-8, so that Go programs can speak protocols built
+// on DataInputStream and DataOutputStream.
+//
+// Methods are named after their Java counterparts, with Go types for the
+// values. ReadByte returns the byte unsigned, as Java's readUnsignedByte does,
+// so that it implements io.ByteReader; convert it to int8 for readByte.
+package datastream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/anders/jutf"
+)
+
+// A Reader reads values from an underlying reader, the way DataInputStream
+// does. It reads no more than it needs to, except that ReadLine may read one
+// byte ahead, which later reads then return.
+//
+// When the input ends in the middle of a value, the error is a
+// *jutf.JavaError for EOFException, wrapping io.EOF if no part of the value
+// was read and io.ErrUnexpectedEOF otherwise.
+type Reader struct {
+	r    io.Reader
+	next int // byte read ahead by ReadLine, or -1
+	buf  [8]byte
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r, next: -1}
+}
+
+// Read implements io.Reader, reading raw bytes.
+func (d *Reader) Read(p []byte) (int, error) {
+	if d.next < 0 || len(p) == 0 {
+		return d.r.Read(p)
+	}
+
+	p[0] = byte(d.next)
+	d.next = -1
+	return 1, nil
+}
+
+// ReadFully reads exactly len(b) bytes into b.
+func (d *Reader) ReadFully(b []byte) error {
+	if _, err := io.ReadFull(d, b); err != nil {
+		return eofError(err)
+	}
+	return nil
+}
+
+// SkipBytes skips up to n bytes, returning how many were skipped, which is
+// fewer only if the input ended.
+func (d *Reader) SkipBytes(n int) (int, error) {
+	m, err := io.CopyN(io.Discard, d, int64(n))
+	if err == io.EOF {
+		err = nil
+	}
+	return int(m), err
+}
+
+// ReadBoolean reads a byte, which is true if it is not zero.
+func (d *Reader) ReadBoolean() (bool, error) {
+	c, err := d.ReadByte()
+	return c != 0, err
+}
+
+// ReadByte reads an unsigned byte.
+func (d *Reader) ReadByte() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadShort reads a signed 16-bit integer.
+func (d *Reader) ReadShort() (int16, error) {
+	v, err := d.ReadUnsignedShort()
+	return int16(v), err
+}
+
+// ReadUnsignedShort reads an unsigned 16-bit integer.
+func (d *Reader) ReadUnsignedShort() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// ReadChar reads a UTF-16 char.
+func (d *Reader) ReadChar() (uint16, error) {
+	return d.ReadUnsignedShort()
+}
+
+// ReadInt reads a signed 32-bit integer.
+func (d *Reader) ReadInt() (int32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+// ReadLong reads a signed 64-bit integer.
+func (d *Reader) ReadLong() (int64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// ReadFloat reads a float in IEEE 754 format.
+func (d *Reader) ReadFloat() (float32, error) {
+	v, err := d.ReadInt()
+	return math.Float32frombits(uint32(v)), err
+}
+
+// ReadDouble reads a double in IEEE 754 format.
+func (d *Reader) ReadDouble() (float64, error) {
+	v, err := d.ReadLong()
+	return math.Float64frombits(uint64(v)), err
+}
+
+// ReadLine reads a line ended by "\n", "\r", "\r\n" or the end of the input,
+// taking each byte as a Latin-1 character as Java does. At the end of the
+// input it returns io.EOF.
+func (d *Reader) ReadLine() (string, error) {
+	var line []byte
+	for {
+		c, err := d.ReadByte()
+		switch {
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return string(line), nil
+		case err != nil:
+			if errors.Is(err, io.EOF) {
+				err = io.EOF
+			}
+			return "", err
+		case c == '\n':
+			return string(line), nil
+		case c == '\r':
+			if c, err := d.ReadByte(); err == nil && c != '\n' {
+				d.next = int(c)
+			}
+			return string(line), nil
+		}
+		line = utf8.AppendRune(line, rune(c))
+	}
+}
+
+// ReadUTF reads a string written by writeUTF, as jutf.ReadUTF.
+func (d *Reader) ReadUTF(opts ...jutf.Option) (string, error) {
+	return jutf.ReadUTF(d, opts...)
+}
+
+// read reads n bytes into d.buf.
+func (d *Reader) read(n int) ([]byte, error) {
+	b := d.buf[:n]
+	if _, err := io.ReadFull(d, b); err != nil {
+		return nil, eofError(err)
+	}
+	return b, nil
+}
+
+// eofError converts the EOF errors from io.ReadFull, as jutf.ReadUTF does.
+func eofError(err error) error {
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return &jutf.JavaError{Exception: jutf.EOFException, Offset: -1, Err: err}
+}
+
+// A Writer writes values to an underlying writer, the way DataOutputStream
+// does. Each value is written with one call to Write, so a bufio.Writer
+// should be used for efficiency.
+type Writer struct {
+	w   io.Writer
+	n   int64
+	buf [8]byte
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Size returns the number of bytes written so far.
+func (e *Writer) Size() int64 {
+	return e.n
+}
+
+// Write implements io.Writer, writing raw bytes.
+func (e *Writer) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	e.n += int64(n)
+	return n, err
+}
+
+// WriteBoolean writes v as a byte, 1 for true and 0 for false.
+func (e *Writer) WriteBoolean(v bool) error {
+	if v {
+		return e.WriteByte(1)
+	}
+	return e.WriteByte(0)
+}
+
+// WriteByte writes a byte.
+func (e *Writer) WriteByte(c byte) error {
+	e.buf[0] = c
+	return e.write(1)
+}
+
+// WriteShort writes a 16-bit integer.
+func (e *Writer) WriteShort(v int16) error {
+	binary.BigEndian.PutUint16(e.buf[:], uint16(v))
+	return e.write(2)
+}
+
+// WriteChar writes a UTF-16 char.
+func (e *Writer) WriteChar(c uint16) error {
+	binary.BigEndian.PutUint16(e.buf[:], c)
+	return e.write(2)
+}
+
+// WriteInt writes a 32-bit integer.
+func (e *Writer) WriteInt(v int32) error {
+	binary.BigEndian.PutUint32(e.buf[:], uint32(v))
+	return e.write(4)
+}
+
+// WriteLong writes a 64-bit integer.
+func (e *Writer) WriteLong(v int64) error {
+	binary.BigEndian.PutUint64(e.buf[:], uint64(v))
+	return e.write(8)
+}
+
+// WriteFloat writes a float in IEEE 754 format.
+func (e *Writer) WriteFloat(v float32) error {
+	return e.WriteInt(int32(math.Float32bits(v)))
+}
+
+// WriteDouble writes a double in IEEE 754 format.
+func (e *Writer) WriteDouble(v float64) error {
+	return e.WriteLong(int64(math.Float64bits(v)))
+}
+
+// WriteBytes writes the low byte of each UTF-16 char of s, as Java does,
+// which keeps Latin-1 text intact.
+func (e *Writer) WriteBytes(s string) error {
+	b := make([]byte, 0, len(s))
+	for _, c := range utf16Chars(s) {
+		b = append(b, byte(c))
+	}
+	_, err := e.Write(b)
+	return err
+}
+
+// WriteChars writes each UTF-16 char of s as 2 bytes.
+func (e *Writer) WriteChars(s string) error {
+	chars := utf16Chars(s)
+	b := make([]byte, 0, 2*len(chars))
+	for _, c := range chars {
+		b = binary.BigEndian.AppendUint16(b, c)
+	}
+	_, err := e.Write(b)
+	return err
+}
+
+// WriteUTF writes s as jutf.WriteUTF does.
+func (e *Writer) WriteUTF(s string) error {
+	return jutf.WriteUTF(e, s)
+}
+
+func (e *Writer) write(n int) error {
+	_, err := e.Write(e.buf[:n])
+	return err
+}
+
+// utf16Chars returns the UTF-16 chars of s, with invalid UTF-8 as U+FFFD.
+func utf16Chars(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
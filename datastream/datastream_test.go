@@ -0,0 +1,122 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package datastream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/anders/jutf"
+)
+
+// written by DataOutputStream for the calls in TestWriter.
+var javaBytes = []byte{
+	0x01,
+	0xfe,
+	0xff, 0xfe,
+	0x00, 0x41,
+	0x01, 0x02, 0x03, 0x04,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x3f, 0xc0, 0x00, 0x00,
+	0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x61, 0xe9,
+	0x00, 0x61, 0xd8, 0x3d, 0xdc, 0xa9,
+	0x00, 0x03, 0x61, 0xc0, 0x80,
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, err := range []error{
+		w.WriteBoolean(true),
+		w.WriteByte(0xfe),
+		w.WriteShort(-2),
+		w.WriteChar('A'),
+		w.WriteInt(0x01020304),
+		w.WriteLong(-1),
+		w.WriteFloat(1.5),
+		w.WriteDouble(1.5),
+		w.WriteBytes("a\u00e9"),
+		w.WriteChars("a\U0001f4a9"),
+		w.WriteUTF("a\x00"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(buf.Bytes(), javaBytes) {
+		t.Errorf("Writer wrote % x, want % x", buf.Bytes(), javaBytes)
+	}
+	if w.Size() != int64(len(javaBytes)) {
+		t.Errorf("Size() = %d, want %d", w.Size(), len(javaBytes))
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(bytes.NewReader(javaBytes))
+	check := func(name string, got, want any, err error) {
+		t.Helper()
+		if got != want || err != nil {
+			t.Errorf("%s() = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	v1, err := r.ReadBoolean()
+	check("ReadBoolean", v1, true, err)
+	v2, err := r.ReadByte()
+	check("ReadByte", int8(v2), int8(-2), err)
+	v3, err := r.ReadShort()
+	check("ReadShort", v3, int16(-2), err)
+	v4, err := r.ReadChar()
+	check("ReadChar", v4, uint16('A'), err)
+	v5, err := r.ReadInt()
+	check("ReadInt", v5, int32(0x01020304), err)
+	v6, err := r.ReadLong()
+	check("ReadLong", v6, int64(-1), err)
+	v7, err := r.ReadFloat()
+	check("ReadFloat", v7, float32(1.5), err)
+	v8, err := r.ReadDouble()
+	check("ReadDouble", v8, 1.5, err)
+
+	b := make([]byte, 2)
+	err = r.ReadFully(b)
+	check("ReadFully", string(b), "a\xe9", err)
+	n, err := r.SkipBytes(6)
+	check("SkipBytes", n, 6, err)
+	s, err := r.ReadUTF()
+	check("ReadUTF", s, "a\x00", err)
+
+	var je *jutf.JavaError
+	if _, err := r.ReadInt(); !errors.As(err, &je) || je.Exception != jutf.EOFException || !errors.Is(err, io.EOF) {
+		t.Errorf("ReadInt() at end error = %v", err)
+	}
+	r = NewReader(bytes.NewReader([]byte{0, 0}))
+	if _, err := r.ReadInt(); !errors.As(err, &je) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadInt() of 2 bytes error = %v", err)
+	}
+	if n, err := r.SkipBytes(1); n != 0 || err != nil {
+		t.Errorf("SkipBytes() at end = %d, %v", n, err)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte("one\ntwo\r\nthree\rfour\xe9\r")))
+	for _, want := range []string{"one", "two", "three", "four\u00e9"} {
+		if got, err := r.ReadLine(); got != want || err != nil {
+			t.Errorf("ReadLine() = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := r.ReadLine(); err != io.EOF {
+		t.Errorf("ReadLine() at end error = %v, want EOF", err)
+	}
+
+	// the byte read ahead after "\r" is not lost
+	r = NewReader(bytes.NewReader([]byte{'a', '\r', 0, 0, 0, 7}))
+	r.ReadLine()
+	if v, err := r.ReadInt(); v != 7 || err != nil {
+		t.Errorf("ReadInt() after ReadLine() = %d, %v", v, err)
+	}
+}
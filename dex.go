@@ -0,0 +1,103 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+)
+
+var errDex = errors.New("not a dex file")
+
+const (
+	dexHeaderSize = 0x70
+	dexBatch      = 1024 // string_ids read at once
+)
+
+// ParseDexStrings reads the strings of a DEX file, in the order of its
+// string_ids section, decoding each from string_data. Only the header and
+// those sections are read. Malformed strings are decoded with U+FFFD in place
+// of the bad sequences, as they are found in obfuscated files. A file that
+// ends before the data it points to is reported as io.ErrUnexpectedEOF.
+func ParseDexStrings(r io.ReaderAt) ([]string, error) {
+	var hdr [dexHeaderSize]byte
+	if err := readDexAt(r, hdr[:], 0); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(hdr[:], []byte("dex\n")) {
+		return nil, errDex
+	}
+	size := int64(binary.LittleEndian.Uint32(hdr[56:]))
+	off := int64(binary.LittleEndian.Uint32(hdr[60:]))
+
+	// the ids are read in batches, so that a bad size in the header does
+	// not allocate more than the file can back up
+	o := options{std: true, onError: Replace}
+	var strs []string
+	var ids, buf []byte
+	for k := int64(0); k < size; k += dexBatch {
+		n := min(size-k, dexBatch)
+		ids = slices.Grow(ids[:0], int(4*n))[:4*n]
+		if err := readDexAt(r, ids, off+4*k); err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < len(ids); i += 4 {
+			var d []byte
+			var err error
+			d, buf, err = readDexString(r, int64(binary.LittleEndian.Uint32(ids[i:])), buf)
+			if err != nil {
+				return nil, err
+			}
+			s, _ := decodeString(d, &o)
+			strs = append(strs, s)
+		}
+	}
+	return strs, nil
+}
+
+// readDexAt reads len(b) bytes at off.
+func readDexAt(r io.ReaderAt, b []byte, off int64) error {
+	if n, err := r.ReadAt(b, off); n < len(b) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// readDexString reads the string_data_item at off: a ULEB128 length in
+// UTF-16 units, which is not needed, and the data up to a NUL byte. It reads
+// into buf, in growing windows, and returns the data and buf.
+func readDexString(r io.ReaderAt, off int64, buf []byte) ([]byte, []byte, error) {
+	buf = buf[:0]
+	for w := 64; ; w *= 2 {
+		start := len(buf)
+		buf = slices.Grow(buf, w)[:start+w]
+		n, err := r.ReadAt(buf[start:], off+int64(start))
+		buf = buf[:start+n]
+
+		br := bytes.NewReader(buf)
+		_, lerr := ReadULEB128(br)
+		switch {
+		case lerr == nil:
+			i := len(buf) - br.Len()
+			if end := bytes.IndexByte(buf[i:], 0); end >= 0 {
+				return buf[i : i+end], buf, nil
+			}
+		case lerr != io.EOF && lerr != io.ErrUnexpectedEOF:
+			return nil, buf, lerr
+		}
+
+		if err == io.EOF {
+			return nil, buf, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, buf, err
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testDex returns a dex file with only a header and the strings ds, with the
+// string_data in reverse order.
+func testDex(ds ...[]byte) []byte {
+	b := make([]byte, dexHeaderSize, dexHeaderSize+4*len(ds))
+	copy(b, "dex\n035\x00")
+	binary.LittleEndian.PutUint32(b[56:], uint32(len(ds)))
+	binary.LittleEndian.PutUint32(b[60:], dexHeaderSize)
+	b = b[:dexHeaderSize+4*len(ds)]
+
+	for k := len(ds) - 1; k >= 0; k-- {
+		binary.LittleEndian.PutUint32(b[dexHeaderSize+4*k:], uint32(len(b)))
+		s, _ := Decode(ds[k], Lossy())
+		b = AppendULEB128(b, uint32(len(utf16.Encode([]rune(s)))))
+		b = append(b, ds[k]...)
+		b = append(b, 0)
+	}
+	return b
+}
+
+func TestParseDexStrings(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	dex := testDex(
+		[]byte("Ljava/lang/Object;"),
+		Encode("a\x00\U0001f4a9"),
+		[]byte("bad \xff"),
+		nil,
+		[]byte(long),
+	)
+	want := []string{"Ljava/lang/Object;", "a\x00\U0001f4a9", "bad \ufffd", "", long}
+
+	got, err := ParseDexStrings(bytes.NewReader(dex))
+	if err != nil || !slices.Equal(got, want) {
+		t.Errorf("ParseDexStrings = %q, %v; want %q", got, err, want)
+	}
+
+	many := make([][]byte, dexBatch+1)
+	for k := range many {
+		many[k] = []byte{'a' + byte(k%26)}
+	}
+	if got, err := ParseDexStrings(bytes.NewReader(testDex(many...))); err != nil || len(got) != len(many) || got[dexBatch] != "k" {
+		t.Errorf("ParseDexStrings(%d strings) = %d strings, %v", len(many), len(got), err)
+	}
+
+	huge := testDex()
+	binary.LittleEndian.PutUint32(huge[56:], 0xffffffff)
+	for _, cut := range [][]byte{dex[:dexHeaderSize-1], dex[:dexHeaderSize+3], dex[:len(dex)-1], huge} {
+		if _, err := ParseDexStrings(bytes.NewReader(cut)); err != io.ErrUnexpectedEOF {
+			t.Errorf("ParseDexStrings(%d bytes) error = %v, want %v", len(cut), err, io.ErrUnexpectedEOF)
+		}
+	}
+
+	if _, err := ParseDexStrings(bytes.NewReader(make([]byte, dexHeaderSize))); err != errDex {
+		t.Errorf("ParseDexStrings(zeros) error = %v, want %v", err, errDex)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+// Sequences are checked with a small state machine in the style of Björn
+// Höhrmann's UTF-8 decoder, extended for the modified forms: each byte is
+// mapped to a class, and the class and current state select the next state.
+
+// byte classes.
+const (
+	cNUL   = iota // 00
+	cASCII        // 01..7f
+	c80           // 80
+	cCont1        // 81..8f
+	cCont2        // 90..9f
+	cCont3        // a0..af
+	cCont4        // b0..bf
+	cC0           // c0
+	cBad          // c1, f5..ff
+	cLead2        // c2..df
+	cE0           // e0
+	cLead3        // e1..ec, ee..ef
+	cED           // ed
+	cF0           // f0
+	cLead4        // f1..f3
+	cF4           // f4
+	numClasses
+)
+
+// states. Those from sAccept on are final.
+const (
+	sStart    = iota
+	sNeed1    // one continuation byte left
+	sNeed2    // two continuation bytes left
+	sC0       // c0, must be followed by 80
+	sE0       // e0, second byte a0..bf
+	sED       // ed, second byte decides if it's a surrogate
+	sLow      // ed b0..bf, lone second half of a surrogate pair
+	sHigh1    // ed a0..af, first half of a surrogate pair
+	sHigh2    // complete first half, must be followed by ed
+	sHigh3    // ed, then b0..bf
+	sHigh4    // then one continuation byte left
+	sF0       // f0, second byte 90..bf
+	sF4       // f4, second byte 80..8f
+	s4Need3   // 4 byte sequence, three continuation bytes left
+	s4Need2   // 4 byte sequence, two continuation bytes left
+	s4Need1   // 4 byte sequence, one continuation byte left
+	sAccept   // complete sequence
+	sNUL      // raw NUL
+	sInvalid  // malformed, the first byte is skipped
+	sUnpaired // unpaired surrogate, 3 bytes
+	sFourByte // complete 4 byte sequence
+	numStates
+)
+
+var (
+	classes [256]uint8
+	trans   [sAccept][numClasses]uint8
+)
+
+func init() {
+	set := func(lo, hi int, c uint8) {
+		for i := lo; i <= hi; i++ {
+			classes[i] = c
+		}
+	}
+	set(0x00, 0x00, cNUL)
+	set(0x01, 0x7f, cASCII)
+	set(0x80, 0x80, c80)
+	set(0x81, 0x8f, cCont1)
+	set(0x90, 0x9f, cCont2)
+	set(0xa0, 0xaf, cCont3)
+	set(0xb0, 0xbf, cCont4)
+	set(0xc0, 0xc0, cC0)
+	set(0xc1, 0xc1, cBad)
+	set(0xc2, 0xdf, cLead2)
+	set(0xe0, 0xe0, cE0)
+	set(0xe1, 0xef, cLead3)
+	set(0xed, 0xed, cED)
+	set(0xf0, 0xf0, cF0)
+	set(0xf1, 0xf3, cLead4)
+	set(0xf4, 0xf4, cF4)
+	set(0xf5, 0xff, cBad)
+
+	// anything not listed below is malformed
+	for st := range trans {
+		for c := range trans[st] {
+			trans[st][c] = sInvalid
+		}
+	}
+
+	// next sets the next state for the given classes.
+	next := func(st, to uint8, cs ...uint8) {
+		for _, c := range cs {
+			trans[st][c] = to
+		}
+	}
+	conts := []uint8{c80, cCont1, cCont2, cCont3, cCont4}
+
+	next(sStart, sNUL, cNUL)
+	next(sStart, sAccept, cASCII)
+	next(sStart, sC0, cC0)
+	next(sStart, sNeed1, cLead2)
+	next(sStart, sE0, cE0)
+	next(sStart, sNeed2, cLead3)
+	next(sStart, sED, cED)
+	next(sStart, sF0, cF0)
+	next(sStart, s4Need3, cLead4)
+	next(sStart, sF4, cF4)
+
+	next(sNeed1, sAccept, conts...)
+	next(sNeed2, sNeed1, conts...)
+	next(sC0, sAccept, c80)
+	next(sE0, sNeed1, cCont3, cCont4)
+
+	next(sED, sNeed1, c80, cCont1, cCont2)
+	next(sED, sHigh1, cCont3)
+	next(sED, sLow, cCont4)
+	next(sLow, sUnpaired, conts...)
+
+	next(sHigh1, sHigh2, conts...)
+	for c := range trans[sHigh2] {
+		trans[sHigh2][c] = sUnpaired
+		trans[sHigh3][c] = sUnpaired
+		trans[sHigh4][c] = sUnpaired
+	}
+	next(sHigh2, sHigh3, cED)
+	next(sHigh3, sHigh4, cCont4)
+	next(sHigh4, sAccept, conts...)
+
+	next(sF0, s4Need2, cCont2, cCont3, cCont4)
+	next(sF4, s4Need2, c80, cCont1)
+	next(s4Need3, s4Need2, conts...)
+	next(s4Need2, s4Need1, conts...)
+	next(s4Need1, sFourByte, conts...)
+}
+
+// scan returns the length of the sequence at the start of d, and why it is
+// not canonical modified UTF-8 if that is the case. For malformed input n is
+// the number of bytes the problem spans.
+func scan(d []byte) (n int, err error) {
+	st := uint8(sStart)
+
+	for i, c := range d {
+		st = trans[st][classes[c]]
+		if st < sAccept {
+			continue
+		}
+
+		switch st {
+		case sAccept:
+			return i + 1, nil
+		case sNUL:
+			// a short NUL, valid and reasonable except this is Java UTF-8.
+			return 1, ErrInvalidNUL
+		case sUnpaired:
+			return 3, ErrUnpairedSurrogate
+		case sFourByte:
+			// only valid in standard UTF-8
+			return i + 1, ErrFourByte
+		}
+		return 1, ErrInvalidEncoding
+	}
+
+	// ran out of input in the middle of a sequence
+	if st >= sHigh2 && st <= sHigh4 {
+		return len(d), ErrTooShortSurrogate
+	}
+	return len(d), ErrTooShort
+}
@@ -0,0 +1,64 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestScanAgreesWithUTF8(t *testing.T) {
+	// every 3 byte input: apart from the modified forms, scan must accept
+	// exactly what package utf8 accepts.
+	d := make([]byte, 3)
+	for i := 0; i < 1<<24; i++ {
+		d[0], d[1], d[2] = byte(i>>16), byte(i>>8), byte(i)
+		if d[0] < 0x80 {
+			continue
+		}
+
+		n, err := scan(d)
+		r, size := utf8.DecodeRune(d)
+		valid := r != utf8.RuneError || size > 1
+
+		switch {
+		case d[0] == 0xc0 && d[1] == 0x80 || d[0] == 0xed && d[1] >= 0xa0 && d[1] <= 0xbf && d[2]&0xc0 == 0x80:
+			// modified forms
+		case valid && (err != nil || n != size):
+			t.Fatalf("scan(%x) = %d, %v; want %d", d, n, err, size)
+		case !valid && err == nil:
+			t.Fatalf("scan(%x) = %d, want error", d, n)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		data []byte
+		n    int
+		err  error
+	}{
+		{[]byte{0xc0, 0x80, 'a'}, 2, nil},
+		{[]byte{0xc0, 0x81}, 1, ErrInvalidEncoding},
+		{[]byte{0xc1}, 1, ErrInvalidEncoding},
+		{[]byte{0xe6, 0x97}, 2, ErrTooShort},
+		{[]byte{0xe0, 0x80, 0x80}, 1, ErrInvalidEncoding},
+		{[]byte{0xed, 0x9f, 0xbf}, 3, nil},
+		{[]byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, 6, nil},
+		{[]byte{0xed, 0xa0, 0xbd, 0xed, 0xb2}, 5, ErrTooShortSurrogate},
+		{[]byte{0xed, 0xa0, 0xbd, 0xed, 0x9f, 0xbf}, 3, ErrUnpairedSurrogate},
+		{[]byte{0xed, 0xa0, 0xbd, 'a'}, 3, ErrUnpairedSurrogate},
+		{[]byte{0xed, 0xb2, 0xa9}, 3, ErrUnpairedSurrogate},
+		{[]byte{0xed, 0xa0}, 2, ErrTooShort},
+		{[]byte{0xf0, 0x9f, 0x92, 0xa9}, 4, ErrFourByte},
+		{[]byte{0xf0, 0x9f, 0x92}, 3, ErrTooShort},
+		{[]byte{0xf4, 0x90, 0x80, 0x80}, 1, ErrInvalidEncoding},
+		{[]byte{0}, 1, ErrInvalidNUL},
+	}
+	for _, tt := range tests {
+		if n, err := scan(tt.data); n != tt.n || err != tt.err {
+			t.Errorf("scan(%x) = %d, %v; want %d, %v", tt.data, n, err, tt.n, tt.err)
+		}
+	}
+}
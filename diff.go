@@ -0,0 +1,168 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "bytes"
+
+// An Edit is a region where two encoded strings differ: the runes of a from
+// AStart up to AEnd are replaced by those of b from BStart up to BEnd. One of
+// the two may be empty, for an insertion or deletion.
+type Edit struct {
+	AStart, AEnd Position
+	BStart, BEnd Position
+}
+
+// Diff compares a and b rune by rune and returns the regions where they
+// differ, in order, with positions in bytes, runes and Java chars. Runes
+// are equal if their encodings are, so an encoded NUL differs from a raw
+// one. The edits are minimal, as found by Myers' algorithm, and nil if a and
+// b are equal.
+func Diff(a, b []byte) []Edit {
+	pa, pb := sequences(a), sequences(b)
+	na, nb := len(pa)-1, len(pb)-1
+	eq := func(i, j int) bool {
+		return bytes.Equal(a[pa[i].Byte:pa[i+1].Byte], b[pb[j].Byte:pb[j+1].Byte])
+	}
+
+	// the common prefix and suffix need no search
+	start := 0
+	for start < na && start < nb && eq(start, start) {
+		start++
+	}
+	end := 0
+	for end < na-start && end < nb-start && eq(na-1-end, nb-1-end) {
+		end++
+	}
+
+	var edits []Edit
+	i, j := start, start
+	add := func(ei, ej int) {
+		if ei > i || ej > j {
+			edits = append(edits, Edit{pa[i], pa[ei], pb[j], pb[ej]})
+		}
+	}
+	for _, m := range myers(na-start-end, nb-start-end, func(x, y int) bool { return eq(start+x, start+y) }) {
+		add(start+m[0], start+m[1])
+		i, j = start+m[0]+1, start+m[1]+1
+	}
+	add(na-end, nb-end)
+	return edits
+}
+
+// sequences returns the positions of the sequences of d, followed by the
+// position of its end.
+func sequences(d []byte) []Position {
+	ps := []Position{{}}
+	p := Position{}
+	for p.Byte < len(d) {
+		n, err := scan(d[p.Byte:])
+		p.Byte += n
+		p.Rune++
+		if p.Char++; err == nil && n == 6 {
+			p.Char++
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// myers returns the pairs of indices of the elements that are kept by a
+// shortest edit script turning a sequence of length n into one of length m,
+// in order. The elements are compared with eq. It uses the linear space
+// variant of Myers' algorithm, splitting the problem at a middle snake, so
+// that memory does not grow with the number of edits.
+func myers(n, m int, eq func(x, y int) bool) [][2]int {
+	var kept [][2]int
+	vf := make([]int, 2*(n+m)+3)
+	vb := make([]int, 2*(n+m)+3)
+
+	var compare func(x0, x1, y0, y1 int)
+	compare = func(x0, x1, y0, y1 int) {
+		// a common prefix and suffix are kept as is
+		for x0 < x1 && y0 < y1 && eq(x0, y0) {
+			kept = append(kept, [2]int{x0, y0})
+			x0++
+			y0++
+		}
+		suffix := 0
+		for x0 < x1-suffix && y0 < y1-suffix && eq(x1-1-suffix, y1-1-suffix) {
+			suffix++
+		}
+		x1, y1 = x1-suffix, y1-suffix
+
+		if x0 < x1 && y0 < y1 {
+			xs, ys, xe, ye := middleSnake(x0, x1, y0, y1, eq, vf, vb)
+			compare(x0, xs, y0, ys)
+			for ; xs < xe; xs, ys = xs+1, ys+1 {
+				kept = append(kept, [2]int{xs, ys})
+			}
+			compare(xe, x1, ye, y1)
+		}
+
+		for k := 0; k < suffix; k++ {
+			kept = append(kept, [2]int{x1 + k, y1 + k})
+		}
+	}
+	compare(0, n, 0, m)
+	return kept
+}
+
+// middleSnake returns the start and end of the diagonal in the middle of a
+// shortest edit script from x0, y0 to x1, y1, found by searching forward
+// from the start and backward from the end until the two meet. vf and vb
+// hold the furthest x reached on each diagonal, forward and backward; they
+// must have room for 2*(x1-x0+y1-y0)+3 entries.
+func middleSnake(x0, x1, y0, y1 int, eq func(x, y int) bool, vf, vb []int) (xs, ys, xe, ye int) {
+	n, m := x1-x0, y1-y0
+	delta := n - m
+	odd := delta&1 != 0
+	off := n + m + 1
+	vf[off+1], vb[off+1] = 0, 0
+
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && vf[off+k-1] < vf[off+k+1] {
+				x = vf[off+k+1] // down, an insertion
+			} else {
+				x = vf[off+k-1] + 1 // right, a deletion
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && eq(x0+x, y0+y) {
+				x++
+				y++
+			}
+			vf[off+k] = x
+
+			// the backward search of round d-1 reached diagonal k
+			// from the other side
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && x+vb[off+kb] >= n {
+				return x0 + sx, y0 + sy, x0 + x, y0 + y
+			}
+		}
+
+		// the same, in from the end
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && vb[off+k-1] < vb[off+k+1] {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && eq(x1-1-x, y1-1-y) {
+				x++
+				y++
+			}
+			vb[off+k] = x
+
+			if kf := delta - k; !odd && kf >= -d && kf <= d && x+vf[off+kf] >= n {
+				return x1 - x, y1 - y, x1 - sx, y1 - sy
+			}
+		}
+	}
+	panic("unreachable")
+}
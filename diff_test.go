@@ -0,0 +1,129 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	pair := "\xed\xa0\xbd\xed\xb2\xa9"
+	tests := []struct {
+		a, b string
+		want []Edit
+	}{
+		{"", "", nil},
+		{"abc", "abc", nil},
+		{"abc", "abd", []Edit{{Position{2, 2, 2}, Position{3, 3, 3}, Position{2, 2, 2}, Position{3, 3, 3}}}},
+		{"abc", "ac", []Edit{{Position{1, 1, 1}, Position{2, 2, 2}, Position{1, 1, 1}, Position{1, 1, 1}}}},
+		{"", "x", []Edit{{Position{}, Position{}, Position{}, Position{1, 1, 1}}}},
+		{
+			// positions after a pair differ in bytes, runes and chars
+			pair + "a\xc0\x80b", pair + "a\x00b",
+			[]Edit{{Position{7, 2, 3}, Position{9, 3, 4}, Position{7, 2, 3}, Position{8, 3, 4}}},
+		},
+		{
+			"x" + pair + "y", "x\xed\xa0\xbd\xed\xb2\xaay",
+			[]Edit{{Position{1, 1, 1}, Position{7, 2, 3}, Position{1, 1, 1}, Position{7, 2, 3}}},
+		},
+		{
+			"abcabba", "cbabac",
+			[]Edit{
+				{Position{0, 0, 0}, Position{1, 1, 1}, Position{0, 0, 0}, Position{1, 1, 1}},
+				{Position{2, 2, 2}, Position{3, 3, 3}, Position{2, 2, 2}, Position{2, 2, 2}},
+				{Position{5, 5, 5}, Position{6, 6, 6}, Position{4, 4, 4}, Position{4, 4, 4}},
+				{Position{7, 7, 7}, Position{7, 7, 7}, Position{5, 5, 5}, Position{6, 6, 6}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := Diff([]byte(tt.a), []byte(tt.b)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Diff(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestDiffRandom checks that the edits turn a into b, and that they are
+// minimal by comparing with the longest common subsequence.
+func TestDiffRandom(t *testing.T) {
+	alphabet := []string{"a", "b", "\xc0\x80", "\xed\xa0\xbd\xed\xb2\xa9", "\xff"}
+	rnd := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		s := make([]string, rnd.Intn(12))
+		for i := range s {
+			s[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return s
+	}
+	join := func(s []string) []byte {
+		var b []byte
+		for _, r := range s {
+			b = append(b, r...)
+		}
+		return b
+	}
+
+	for n := 0; n < 1000; n++ {
+		sa, sb := gen(), gen()
+		a, b := join(sa), join(sb)
+		edits := Diff(a, b)
+
+		var out []byte
+		last, changed := 0, 0
+		for _, e := range edits {
+			out = append(out, a[last:e.AStart.Byte]...)
+			out = append(out, b[e.BStart.Byte:e.BEnd.Byte]...)
+			last = e.AEnd.Byte
+			changed += e.AEnd.Rune - e.AStart.Rune + e.BEnd.Rune - e.BStart.Rune
+		}
+		out = append(out, a[last:]...)
+		if string(out) != string(b) {
+			t.Fatalf("Diff(%q, %q) = %v, applies to %q", a, b, edits, out)
+		}
+
+		if want := len(sa) + len(sb) - 2*lcs(sa, sb); changed != want {
+			t.Fatalf("Diff(%q, %q) changes %d runes, want %d", a, b, changed, want)
+		}
+	}
+}
+
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+// TestDiffMemory checks that the memory used does not grow with the square
+// of the number of edits, which for strings with nothing in common is their
+// length.
+func TestDiffMemory(t *testing.T) {
+	a, b := bytes.Repeat([]byte("a"), 4000), bytes.Repeat([]byte("b"), 4000)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits := Diff(a, b)
+	runtime.ReadMemStats(&after)
+
+	if len(edits) != 1 || edits[0].AEnd.Byte != 4000 || edits[0].BEnd.Byte != 4000 {
+		t.Errorf("Diff = %v, want one edit replacing everything", edits)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 4<<20 {
+		t.Errorf("Diff allocated %d bytes", n)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// bytes per row of a dump.
+const dumpWidth = 16
+
+// Dump writes a hexdump of b to w. Encoded NULs, surrogate pairs and
+// sequences that are not canonical modified UTF-8 are put in brackets and
+// described at the end of the row. Rows are cut short rather than split a
+// sequence.
+//
+//	00000000  48 69 [c0 80] 21 [ed a0 bd ed b2 a9]                     |Hi..!......|       U+0000 U+1F4A9
+func Dump(w io.Writer, b []byte) error {
+	// room for the brackets of a few sequences; rows with more are wider
+	const hexWidth = 3*dumpWidth + 8
+
+	var line, hex, text, notes []byte
+	for i := 0; i < len(b); {
+		row := i
+		hex, text, notes = hex[:0], text[:0], notes[:0]
+
+		for i < len(b) {
+			r, n := DecodeRune(b[i:])
+			if i+n-row > dumpWidth {
+				break
+			}
+
+			note := len(notes)
+			notes = appendNote(notes, b[i:i+n], r)
+			marked := len(notes) > note
+
+			hex = append(hex, ' ')
+			if marked {
+				hex = append(hex, '[')
+			}
+			for k, c := range b[i : i+n] {
+				if k > 0 {
+					hex = append(hex, ' ')
+				}
+				hex = appendHex(hex, c)
+
+				if c >= 0x20 && c < 0x7f {
+					text = append(text, c)
+				} else {
+					text = append(text, '.')
+				}
+			}
+			if marked {
+				hex = append(hex, ']')
+			}
+			i += n
+		}
+
+		line = line[:0]
+		for shift := 24; shift >= 0; shift -= 8 {
+			line = appendHex(line, byte(row>>shift))
+		}
+		line = append(line, ' ')
+		line = append(line, hex...)
+		line = appendSpaces(line, hexWidth-len(hex))
+		line = append(line, "  |"...)
+		line = append(line, text...)
+		line = append(line, '|')
+		if len(notes) > 0 {
+			line = appendSpaces(line, dumpWidth-len(text)+1)
+			line = append(line, notes...)
+		}
+		line = append(line, '\n')
+
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendNote appends a description of the sequence seq decoding to r to
+// notes, unless it is an ordinary one.
+func appendNote(notes, seq []byte, r rune) []byte {
+	switch {
+	case len(seq) == 1 && r == 0:
+		return append(notes, " raw-NUL"...)
+	case r == 0, len(seq) == 6:
+		return appendCodePoint(append(notes, ' '), r)
+	case len(seq) == 4:
+		return appendCodePoint(append(notes, " 4-byte:"...), r)
+	case r == utf8.RuneError && string(seq) != "\ufffd":
+		return append(notes, " invalid"...)
+	}
+	return notes
+}
+
+// appendCodePoint appends r in the form U+0041.
+func appendCodePoint(b []byte, r rune) []byte {
+	const digits = "0123456789ABCDEF"
+
+	b = append(b, "U+"...)
+	if r > 0xfffff {
+		b = append(b, digits[r>>20])
+	}
+	if r > 0xffff {
+		b = append(b, digits[r>>16&0xf])
+	}
+	return appendHexUpper(appendHexUpper(b, byte(r>>8)), byte(r))
+}
+
+func appendHex(b []byte, c byte) []byte {
+	const digits = "0123456789abcdef"
+	return append(b, digits[c>>4], digits[c&0xf])
+}
+
+func appendHexUpper(b []byte, c byte) []byte {
+	const digits = "0123456789ABCDEF"
+	return append(b, digits[c>>4], digits[c&0xf])
+}
+
+func appendSpaces(b []byte, n int) []byte {
+	for ; n > 0; n-- {
+		b = append(b, ' ')
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"Hi\xc0\x80!\xed\xa0\xbd\xed\xb2\xa9", []string{
+			"00000000  48 69 [c0 80] 21 [ed a0 bd ed b2 a9]                     |Hi..!......|       U+0000 U+1F4A9",
+		}},
+		{"\xff\x00 \xf0\x9f\x92\xa9 \xed\xa0\x80\xef\xbf\xbd", []string{
+			"00000000  [ff] [00] 20 [f0 9f 92 a9] 20 [ed a0 80] ef bf bd        |.. .... ......|    invalid raw-NUL 4-byte:U+1F4A9 invalid",
+		}},
+		{"\xed\xaf\xbf\xed\xbf\xbf", []string{
+			"00000000  [ed af bf ed bf bf]                                      |......|            U+10FFFF",
+		}},
+		{strings.Repeat("a", 12) + "\xed\xa0\xbd\xed\xb2\xa9", []string{
+			"00000000  61 61 61 61 61 61 61 61 61 61 61 61                      |aaaaaaaaaaaa|",
+			"0000000c  [ed a0 bd ed b2 a9]                                      |......|            U+1F4A9",
+		}},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := Dump(&sb, []byte(tt.in)); err != nil {
+			t.Errorf("Dump(%q) error = %v", tt.in, err)
+		}
+		want := ""
+		for _, line := range tt.want {
+			want += line + "\n"
+		}
+		if got := sb.String(); got != want {
+			t.Errorf("Dump(%q) =\n%s\nwant:\n%s", tt.in, got, want)
+		}
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestDumpError(t *testing.T) {
+	if err := Dump(errWriter{}, []byte("abc")); err == nil {
+		t.Errorf("Dump() returned no error")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Errors reported by Decode, wrapped in a *DecodeError, and ValidateAll.
+var (
+	ErrInvalidNUL        = errors.New("short NUL codepoint not allowed")
+	ErrTooShort          = errors.New("unexpected end of data")
+	ErrTooShortSurrogate = errors.New("unexpected end of data (missing surrogate)")
+	ErrInvalidEncoding   = errors.New("invalid encoding")
+	ErrUnpairedSurrogate = errors.New("unpaired surrogate")
+	ErrFourByte          = errors.New("4-byte sequence not allowed")
+)
+
+// number of bytes shown on either side of the offending byte.
+const contextLen = 8
+
+// A DecodeError describes malformed input and where it was found.
+type DecodeError struct {
+	Offset int   // byte offset of the malformed sequence
+	Err    error // one of the Err* values above
+
+	// a copy of the bytes surrounding Offset, starting at ctxStart.
+	ctx      []byte
+	ctxStart int
+	ctxMore  bool // input continues past ctx
+}
+
+func newDecodeError(d []byte, offset int, err error) *DecodeError {
+	start := offset - contextLen
+	if start < 0 {
+		start = 0
+	}
+	end := offset + contextLen + 1
+	if end > len(d) {
+		end = len(d)
+	}
+
+	e := &DecodeError{Offset: offset, Err: err, ctxStart: start, ctxMore: end < len(d)}
+	if start < end {
+		e.ctx = append([]byte(nil), d[start:end]...)
+	}
+	return e
+}
+
+// Error returns the cause and offset followed by a hex dump of the bytes
+// around it, with the offending byte in brackets:
+//
+//	invalid encoding at offset 12: 61 62 63 [ff] 64
+func (e *DecodeError) Error() string {
+	const hex = "0123456789abcdef"
+
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	b.WriteString(" at offset ")
+	b.WriteString(strconv.Itoa(e.Offset))
+
+	if len(e.ctx) == 0 {
+		return b.String()
+	}
+
+	b.WriteString(":")
+	if e.ctxStart > 0 {
+		b.WriteString(" ...")
+	}
+	for i, c := range e.ctx {
+		b.WriteByte(' ')
+		at := e.ctxStart+i == e.Offset
+		if at {
+			b.WriteByte('[')
+		}
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+		if at {
+			b.WriteByte(']')
+		}
+	}
+	if e.ctxMore {
+		b.WriteString(" ...")
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error, so that errors.Is(err, ErrTooShort)
+// works as expected.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,42 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int
+		err    error
+		msg    string
+	}{
+		{"short", []byte{'a', 0xed, 0xa0, 0xbd}, 1, ErrTooShortSurrogate,
+			"unexpected end of data (missing surrogate) at offset 1: 61 [ed] a0 bd"},
+		{"NUL", []byte{0xc0, 0x80, 0}, 2, ErrInvalidNUL,
+			"short NUL codepoint not allowed at offset 2: c0 80 [00]"},
+		{"context", append(bytes.Repeat([]byte("a"), 20), append([]byte{0xff}, bytes.Repeat([]byte("b"), 20)...)...), 20, ErrInvalidEncoding,
+			"invalid encoding at offset 20: ... 61 61 61 61 61 61 61 61 [ff] 62 62 62 62 62 62 62 62 ..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("Decode() error = %v, want *DecodeError", err)
+			}
+			if de.Offset != tt.offset || !errors.Is(err, tt.err) {
+				t.Errorf("Decode() error at %d (%v), want %d (%v)", de.Offset, de.Err, tt.offset, tt.err)
+			}
+			if msg := err.Error(); msg != tt.msg {
+				t.Errorf("Error() = %q, want %q", msg, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// DecodeFile decodes the file src and writes the result to dst, creating or
+// truncating it. The file is streamed through a Decoder, so memory use does
+// not depend on its size, and standard UTF-8 forms are accepted anywhere
+// unless the Strict option is used. If decoding fails, dst is removed.
+func DecodeFile(dst, src string, opts ...Option) error {
+	return transcodeFile(dst, src, func(w io.Writer, r io.Reader) error {
+		_, err := io.Copy(w, NewDecoder(r, opts...))
+		return err
+	})
+}
+
+// EncodeFile encodes the file src and writes the result to dst, creating or
+// truncating it. The file is streamed through an Encoder, so memory use does
+// not depend on its size. If encoding fails, dst is removed.
+func EncodeFile(dst, src string, opts ...Option) error {
+	return transcodeFile(dst, src, func(w io.Writer, r io.Reader) error {
+		e := NewEncoder(w, opts...)
+		if _, err := io.Copy(e, r); err != nil {
+			return err
+		}
+		return e.Close()
+	})
+}
+
+// transcodeFile opens src and dst and calls fn to copy from one to the
+// other.
+func transcodeFile(dst, src string, fn func(w io.Writer, r io.Reader) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(out, bufSize)
+	if err = fn(w, bufio.NewReaderSize(in, bufSize)); err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	text := strings.Repeat("abc\x00\U0001f4a9å", 5000)
+
+	plain := filepath.Join(dir, "plain")
+	encoded := filepath.Join(dir, "encoded")
+	decoded := filepath.Join(dir, "decoded")
+	if err := os.WriteFile(plain, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncodeFile(encoded, plain); err != nil {
+		t.Fatalf("EncodeFile() = %v", err)
+	}
+	if got, _ := os.ReadFile(encoded); string(got) != string(Encode(text)) {
+		t.Errorf("EncodeFile() wrote %d bytes, want %d", len(got), len(Encode(text)))
+	}
+
+	if err := DecodeFile(decoded, encoded); err != nil {
+		t.Fatalf("DecodeFile() = %v", err)
+	}
+	if got, _ := os.ReadFile(decoded); string(got) != text {
+		t.Errorf("DecodeFile() wrote %d bytes, want %d", len(got), len(text))
+	}
+}
+
+func TestDecodeFileError(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.WriteFile(src, []byte{'a', 0xff}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DecodeFile(dst, src); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("DecodeFile() = %v, want %v", err, ErrInvalidEncoding)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("DecodeFile() left %s behind", dst)
+	}
+
+	if err := DecodeFile(dst, filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("DecodeFile() = %v, want not exist", err)
+	}
+}
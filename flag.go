@@ -0,0 +1,133 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errBadEscape = errors.New("invalid escape")
+
+// A Literal is encoded data written as text, for debugging tools that take
+// exact byte sequences on the command line. It implements flag.Value.
+//
+// Set accepts either "hex:" followed by hex digits, which may be separated
+// by spaces, or text with escapes: \uXXXX for a UTF-16 char, encoded on its
+// own so that unpaired surrogates can be written, \xHH for a raw byte, and
+// \\, \n, \r and \t. Other characters are encoded as by Encode.
+type Literal []byte
+
+// Set implements flag.Value.
+func (l *Literal) Set(s string) error {
+	if h, ok := strings.CutPrefix(s, "hex:"); ok {
+		b, err := hex.DecodeString(strings.ReplaceAll(h, " ", ""))
+		if err != nil {
+			return err
+		}
+		*l = b
+		return nil
+	}
+
+	var b []byte
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\\')
+		if i < 0 {
+			i = len(s)
+		}
+		b = AppendEncode(b, s[:i])
+		if s = s[i:]; s == "" {
+			break
+		}
+
+		if len(s) < 2 {
+			return errBadEscape
+		}
+		switch s[1] {
+		case '\\':
+			b = append(b, '\\')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'x', 'u':
+			n := 2
+			if s[1] == 'u' {
+				n = 4
+			}
+			if len(s) < 2+n {
+				return errBadEscape
+			}
+			v, err := strconv.ParseUint(s[2:2+n], 16, 16)
+			if err != nil {
+				return errBadEscape
+			}
+			if s[1] == 'x' {
+				b = append(b, byte(v))
+			} else {
+				b = appendRune(b, rune(v))
+			}
+			s = s[n:]
+		default:
+			return errBadEscape
+		}
+		s = s[2:]
+	}
+
+	*l = b
+	return nil
+}
+
+// String implements flag.Value, returning l in the escaped form accepted by
+// Set: printable ASCII as is, other chars as \uXXXX and malformed bytes as
+// \xHH.
+func (l Literal) String() string {
+	const digits = "0123456789abcdef"
+
+	var sb strings.Builder
+	for i := 0; i < len(l); {
+		if c := l[i]; c >= 0x20 && c < 0x7f {
+			if c == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		// each char on its own, so pairs are written as two
+		n, err := scan(l[i:])
+		if err == nil && n == 6 || err == ErrUnpairedSurrogate {
+			n = 3
+		} else if err != nil {
+			for _, c := range l[i : i+n] {
+				sb.WriteString(`\x`)
+				sb.WriteByte(digits[c>>4])
+				sb.WriteByte(digits[c&0xf])
+			}
+			i += n
+			continue
+		}
+
+		var c rune
+		switch n {
+		case 1:
+			c = rune(l[i])
+		case 2:
+			c = rune(l[i]&0x1f)<<6 | rune(l[i+1]&0x3f)
+		default:
+			c = rune(l[i]&0x0f)<<12 | rune(l[i+1]&0x3f)<<6 | rune(l[i+2]&0x3f)
+		}
+		sb.WriteString(`\u`)
+		for shift := 12; shift >= 0; shift -= 4 {
+			sb.WriteByte(digits[c>>shift&0xf])
+		}
+		i += n
+	}
+	return sb.String()
+}
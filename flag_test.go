@@ -0,0 +1,68 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+var _ flag.Value = (*Literal)(nil)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+		str  string
+	}{
+		{"abc", []byte("abc"), "abc"},
+		{`a\\b`, []byte(`a\b`), `a\\b`},
+		{`\u0000`, []byte{0xc0, 0x80}, `\u0000`},
+		{"\x00", []byte{0xc0, 0x80}, `\u0000`},
+		{`å`, []byte("å"), `\u00e5`},
+		{`💩`, Encode("\U0001f4a9"), `\ud83d\udca9`},
+		{`\ud800x`, []byte{0xed, 0xa0, 0x80, 'x'}, `\ud800x`},
+		{`\x00\xff\n`, []byte{0, 0xff, '\n'}, `\x00\xff\u000a`},
+		{`\xf0\x9f\x92\xa9`, []byte("\U0001f4a9"), `\xf0\x9f\x92\xa9`},
+		{"hex:c080 41", []byte{0xc0, 0x80, 'A'}, `\u0000A`},
+	}
+	for _, tt := range tests {
+		var l Literal
+		if err := l.Set(tt.in); err != nil {
+			t.Errorf("Set(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !bytes.Equal(l, tt.want) {
+			t.Errorf("Set(%q) = %x, want %x", tt.in, []byte(l), tt.want)
+		}
+		if got := l.String(); got != tt.str {
+			t.Errorf("Set(%q).String() = %q, want %q", tt.in, got, tt.str)
+		}
+
+		var back Literal
+		if err := back.Set(l.String()); err != nil || !bytes.Equal(back, l) {
+			t.Errorf("Set(%q) = %x, %v; want %x", l.String(), []byte(back), err, []byte(l))
+		}
+	}
+
+	for _, in := range []string{`\`, `\q`, `\u12`, `\uzzzz`, `\x1`, "hex:abc", "hex:zz"} {
+		var l Literal
+		if err := l.Set(in); err == nil {
+			t.Errorf("Set(%q) = %x, want error", in, []byte(l))
+		}
+	}
+}
+
+func TestLiteralFlag(t *testing.T) {
+	var l Literal
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&l, "data", "bytes to write")
+	if err := fs.Parse([]string{"-data", `a\u0000b`}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{'a', 0xc0, 0x80, 'b'}; !bytes.Equal(l, want) {
+		t.Errorf("-data = %x, want %x", []byte(l), want)
+	}
+}
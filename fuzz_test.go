@@ -0,0 +1,160 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"unicode/utf8"
+)
+
+// refDecode is a reference decoder for canonical modified UTF-8, written
+// from the definition in java.io.DataInput rather than for speed. It reports
+// false for anything else.
+func refDecode(d []byte) (string, bool) {
+	// decode to UTF-16 first
+	var chars []rune
+	for i := 0; i < len(d); {
+		c := rune(d[i])
+		switch {
+		case c >= 0x01 && c <= 0x7f:
+			chars = append(chars, c)
+			i++
+		case c>>5 == 0x6 && i+1 < len(d) && d[i+1]>>6 == 0x2:
+			r := (c&0x1f)<<6 | rune(d[i+1]&0x3f)
+			if r != 0 && r < 0x80 {
+				return "", false
+			}
+			chars = append(chars, r)
+			i += 2
+		case c>>4 == 0xe && i+2 < len(d) && d[i+1]>>6 == 0x2 && d[i+2]>>6 == 0x2:
+			r := (c&0x0f)<<12 | rune(d[i+1]&0x3f)<<6 | rune(d[i+2]&0x3f)
+			if r < 0x800 {
+				return "", false
+			}
+			chars = append(chars, r)
+			i += 3
+		default:
+			return "", false
+		}
+	}
+
+	// then combine the surrogate pairs, which must all be paired
+	var s []rune
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		switch {
+		case c >= 0xdc00 && c <= 0xdfff:
+			return "", false
+		case c >= 0xd800 && c <= 0xdbff:
+			if i+1 == len(chars) || chars[i+1] < 0xdc00 || chars[i+1] > 0xdfff {
+				return "", false
+			}
+			c = 0x10000 + (c-0xd800)<<10 + chars[i+1] - 0xdc00
+			i++
+		}
+		s = append(s, c)
+	}
+	return string(s), true
+}
+
+// refEncode is a reference encoder, one rune at a time.
+func refEncode(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		switch {
+		case r == 0:
+			b = append(b, 0xc0, 0x80)
+		case r > 0xffff:
+			r -= 0x10000
+			for _, c := range []rune{0xd800 + r>>10, 0xdc00 + r&0x3ff} {
+				b = append(b, 0xe0|byte(c>>12), 0x80|byte(c>>6)&0x3f, 0x80|byte(c)&0x3f)
+			}
+		default:
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	return b
+}
+
+var fuzzSeeds = []string{
+	"",
+	"java/lang/Object",
+	"a\xc0\x80b",
+	"a\x00b",
+	"\xed\xa0\xbd\xed\xb2\xa9",
+	"\xf0\x9f\x92\xa9",
+	"\xed\xa0\xbd",
+	"\xed\xb2\xa9\xed\xa0\xbd",
+	"\xc0\x80\x00",
+	"\xc1\xbf\xe0\x80\x80",
+	"\xe6\x97",
+	"\u00e5\u65e5\ufffd",
+	"\xff\xfe",
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, d []byte) {
+		want, ok := refDecode(d)
+
+		if Valid(d) != ok {
+			t.Errorf("Valid(%x) = %v, want %v", d, !ok, ok)
+		}
+
+		got, err := Decode(d, Strict())
+		if ok && (err != nil || got != want) {
+			t.Errorf("Decode(%x, Strict()) = %q, %v; want %q", d, got, err, want)
+		}
+		var de *DecodeError
+		if !ok && !errors.As(err, &de) {
+			t.Errorf("Decode(%x, Strict()) error = %v, want a *DecodeError", d, err)
+		}
+
+		// without Strict, all standard UTF-8 is accepted too
+		if !ok && utf8.Valid(d) {
+			want, ok = string(d), true
+		}
+		got, err = Decode(d)
+		if ok != (err == nil) || ok && got != want {
+			t.Errorf("Decode(%x) = %q, %v; want %q, %v", d, got, err, want, ok)
+		}
+
+		var buf bytes.Buffer
+		if _, err := DecodeTo(&buf, d); ok != (err == nil) || ok && buf.String() != want {
+			t.Errorf("DecodeTo(%x) = %q, %v; want %q, %v", d, buf.String(), err, want, ok)
+		}
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		// invalid bytes are encoded as U+FFFD
+		valid := string([]rune(s))
+
+		enc := Encode(s)
+		if want := refEncode(s); !bytes.Equal(enc, want) {
+			t.Fatalf("Encode(%q) = %x, want %x", s, enc, want)
+		}
+		if n := EncodedLen(s); n != len(enc) {
+			t.Errorf("EncodedLen(%q) = %d, want %d", s, n, len(enc))
+		}
+		if !Valid(enc) {
+			t.Errorf("Valid(Encode(%q)) = false", s)
+		}
+
+		if got, err := Decode(enc); err != nil || got != valid {
+			t.Errorf("Decode(Encode(%q)) = %q, %v", s, got, err)
+		}
+		if got, ok := refDecode(enc); !ok || got != valid {
+			t.Errorf("refDecode(Encode(%q)) = %q, %v", s, got, ok)
+		}
+	})
+}
@@ -1,3 +1,5 @@
 module github.com/anders/jutf
 
-go 1.13
+go 1.23.0
+
+require golang.org/x/text v0.26.0
@@ -0,0 +1,102 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+)
+
+var errHPROF = errors.New("not an HPROF file")
+
+// tagHPROFUTF8 is the tag of the records holding the names in a heap dump.
+const tagHPROFUTF8 = 0x01
+
+// BuildHPROFStringTable reads a heap dump in the HPROF format, as written by
+// the JVM, and returns the strings of its UTF8 records by ID: the names of
+// classes, fields and methods that the other records refer to. The dump is
+// read as a stream, skipping the other records. Malformed names are decoded
+// with U+FFFD in place of the bad sequences.
+func BuildHPROFStringTable(r io.Reader) (map[uint64]string, error) {
+	br := bufio.NewReader(r)
+
+	// "JAVA PROFILE 1.0.2\0", the size of IDs and a timestamp
+	magic, err := br.ReadSlice(0)
+	if err != nil || !bytes.HasPrefix(magic, []byte("JAVA PROFILE ")) {
+		return nil, errHPROF
+	}
+	var hdr [12]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	idSize := int(binary.BigEndian.Uint32(hdr[:4]))
+	if idSize != 4 && idSize != 8 {
+		return nil, errHPROF
+	}
+
+	o := options{std: true, onError: Replace}
+	table := make(map[uint64]string)
+	var buf []byte
+	for {
+		// tag, time and length of the body
+		var rec [9]byte
+		if _, err := io.ReadFull(br, rec[:]); err == io.EOF {
+			return table, nil
+		} else if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		n := int(binary.BigEndian.Uint32(rec[5:]))
+		if rec[0] != tagHPROFUTF8 {
+			if _, err := br.Discard(n); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			continue
+		}
+		if n < idSize {
+			return nil, errHPROF
+		}
+
+		var err error
+		if buf, err = readRecord(buf[:0], br, n); err != nil {
+			return nil, err
+		}
+		var id uint64
+		if idSize == 4 {
+			id = uint64(binary.BigEndian.Uint32(buf))
+		} else {
+			id = binary.BigEndian.Uint64(buf)
+		}
+		table[id], _ = decodeString(buf[idSize:], &o)
+	}
+}
+
+// hprofChunk is how much of a record is read at a time, so that the memory
+// used grows with the data actually read, not the length the file claims.
+const hprofChunk = 64 << 10
+
+// readRecord appends the n bytes of a record body from r to b.
+func readRecord(b []byte, r io.Reader, n int) ([]byte, error) {
+	for len(b) < n {
+		m := min(n-len(b), hprofChunk)
+		b = slices.Grow(b, m)
+		k, err := io.ReadFull(r, b[len(b):len(b)+m])
+		if b = b[:len(b)+k]; err != nil {
+			return b, unexpectedEOF(err)
+		}
+	}
+	return b, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for io.EOF, and err otherwise.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"maps"
+	"runtime"
+	"testing"
+)
+
+// testHPROF returns a heap dump with IDs of idSize bytes and the given
+// records, each a tag followed by the body.
+func testHPROF(idSize int, records ...[]byte) []byte {
+	b := append([]byte("JAVA PROFILE 1.0.2\x00"), 0, 0, 0, byte(idSize))
+	b = binary.BigEndian.AppendUint64(b, 1234)
+	for _, rec := range records {
+		b = append(b, rec[0], 0, 0, 0, 0)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(rec)-1))
+		b = append(b, rec[1:]...)
+	}
+	return b
+}
+
+// testUTF8Record returns an HPROF UTF8 record.
+func testUTF8Record(idSize int, id uint64, d []byte) []byte {
+	b := []byte{tagHPROFUTF8}
+	if idSize == 4 {
+		b = binary.BigEndian.AppendUint32(b, uint32(id))
+	} else {
+		b = binary.BigEndian.AppendUint64(b, id)
+	}
+	return append(b, d...)
+}
+
+func TestBuildHPROFStringTable(t *testing.T) {
+	want := map[uint64]string{
+		1:       "java/lang/Object",
+		2:       "a\x00\U0001f4a9",
+		0xffff0: "bad \ufffd",
+		3:       "",
+	}
+	for _, idSize := range []int{4, 8} {
+		dump := testHPROF(idSize,
+			testUTF8Record(idSize, 1, []byte("java/lang/Object")),
+			[]byte{0x02, 1, 2, 3, 4, 5, 6, 7, 8}, // LOAD CLASS, skipped
+			testUTF8Record(idSize, 2, Encode("a\x00\U0001f4a9")),
+			testUTF8Record(idSize, 0xffff0, []byte("bad \xff")),
+			testUTF8Record(idSize, 3, nil),
+		)
+		got, err := BuildHPROFStringTable(bytes.NewReader(dump))
+		if err != nil || !maps.Equal(got, want) {
+			t.Errorf("BuildHPROFStringTable(%d-byte IDs) = %q, %v; want %q", idSize, got, err, want)
+		}
+
+		if _, err := BuildHPROFStringTable(bytes.NewReader(dump[:len(dump)-3])); err != io.ErrUnexpectedEOF {
+			t.Errorf("BuildHPROFStringTable(cut off) error = %v", err)
+		}
+	}
+
+	for _, bad := range [][]byte{
+		[]byte("PK\x03\x04"),
+		testHPROF(2),
+		testHPROF(8, []byte{tagHPROFUTF8, 1, 2}),
+	} {
+		if _, err := BuildHPROFStringTable(bytes.NewReader(bad)); err != errHPROF {
+			t.Errorf("BuildHPROFStringTable(%q) error = %v, want %v", bad, err, errHPROF)
+		}
+	}
+}
+
+func TestBuildHPROFStringTableLength(t *testing.T) {
+	// a UTF8 record claiming 4 GB, followed by little data
+	dump := testHPROF(8, testUTF8Record(8, 1, []byte("java/lang/Object")))
+	binary.BigEndian.PutUint32(dump[len(dump)-24-4:], 0xffffffff)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := BuildHPROFStringTable(bytes.NewReader(dump))
+	runtime.ReadMemStats(&after)
+
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("BuildHPROFStringTable error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("BuildHPROFStringTable allocated %d bytes", n)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "sort"
+
+// A Position is a location in encoded data, in each of the units it can be
+// counted in.
+type Position struct {
+	Byte int // byte offset in the encoded data
+	Rune int // index of the decoded rune
+	Char int // index of the UTF-16 code unit, i.e. the Java char
+}
+
+// An Index converts positions in encoded data between byte offsets, rune
+// indices and Java char indices, as reported by exceptions and debuggers.
+// Malformed sequences are counted as one rune each, as by Analyze.
+type Index struct {
+	d     []byte
+	marks []Position // at least indexStride bytes apart
+}
+
+// bytes between the positions kept by an Index.
+const indexStride = 64
+
+// position units.
+const (
+	byByte = iota
+	byRune
+	byChar
+)
+
+// NewIndex returns an Index for d, which must not be modified while it is in
+// use. Positions are kept every 64 bytes, so that a conversion is a binary
+// search followed by a scan of at most 64 bytes of d, taking O(log n) time
+// however large d is.
+func NewIndex(d []byte) *Index {
+	x := &Index{d: d, marks: []Position{{}}}
+	next := indexStride
+	for p := (Position{}); p.Byte < len(d); {
+		if span := asciiSpan(d[p.Byte:]); span > 0 {
+			for ; next < p.Byte+span; next += indexStride {
+				x.marks = append(x.marks, p.add(next-p.Byte))
+			}
+			p = p.add(span)
+			continue
+		}
+
+		p, _ = x.step(p)
+		if p.Byte >= next {
+			x.marks = append(x.marks, p)
+			next = p.Byte + indexStride
+		}
+	}
+	return x
+}
+
+// ByByte returns the position of the sequence containing byte offset off.
+// For the low half of a surrogate pair, that is the position of its char.
+func (x *Index) ByByte(off int) Position { return x.find(byByte, off) }
+
+// ByRune returns the position of rune index i.
+func (x *Index) ByRune(i int) Position { return x.find(byRune, i) }
+
+// ByChar returns the position of Java char index i. The low half of a
+// surrogate pair has its own byte offset, but shares the rune index of the
+// pair.
+func (x *Index) ByChar(i int) Position { return x.find(byChar, i) }
+
+// End returns the position at the end of the data.
+func (x *Index) End() Position {
+	return x.find(byByte, len(x.d))
+}
+
+// find returns the position of target in the given unit. Targets before the
+// start or past the end give the start or end.
+func (x *Index) find(unit, target int) Position {
+	k := sort.Search(len(x.marks), func(k int) bool {
+		return x.marks[k].get(unit) > target
+	})
+	if k == 0 {
+		return Position{}
+	}
+
+	p := x.marks[k-1]
+	for p.Byte < len(x.d) {
+		if span := asciiSpan(x.d[p.Byte:]); span > 0 {
+			if t := target - p.get(unit); t < span {
+				return p.add(t)
+			}
+			p = p.add(span)
+			continue
+		}
+
+		q, pair := x.step(p)
+		if q.get(unit) > target {
+			if low := (Position{p.Byte + 3, p.Rune, p.Char + 1}); pair && unit != byRune && low.get(unit) <= target {
+				return low
+			}
+			return p
+		}
+		p = q
+	}
+	return p
+}
+
+// step returns the position after the sequence at p, and whether it is a
+// surrogate pair.
+func (x *Index) step(p Position) (Position, bool) {
+	n, err := scan(x.d[p.Byte:])
+	pair := err == nil && n == 6
+	p.Byte += n
+	p.Rune++
+	if p.Char++; pair {
+		p.Char++
+	}
+	return p, pair
+}
+
+// add returns p moved forward by n ASCII bytes.
+func (p Position) add(n int) Position {
+	return Position{p.Byte + n, p.Rune + n, p.Char + n}
+}
+
+func (p Position) get(unit int) int {
+	switch unit {
+	case byRune:
+		return p.Rune
+	case byChar:
+		return p.Char
+	}
+	return p.Byte
+}
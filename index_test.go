@@ -0,0 +1,83 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	text := strings.Repeat("abcdefgh\x00\U0001f4a9åこ", 40) + strings.Repeat("x", 300)
+	d := Encode(text)
+	x := NewIndex(d)
+
+	// every position, and the low halves of pairs
+	var want []Position
+	var p Position
+	for _, r := range text {
+		want = append(want, p)
+		n := len(Encode(string(r)))
+		if n == 6 {
+			want = append(want, Position{p.Byte + 3, p.Rune, p.Char + 1})
+			p.Char++
+		}
+		p = Position{p.Byte + n, p.Rune + 1, p.Char + 1}
+	}
+	end := p
+	want = append(want, end)
+
+	for k, w := range want {
+		if got := x.ByChar(w.Char); got != w {
+			t.Fatalf("ByChar(%d) = %+v, want %+v", w.Char, got, w)
+		}
+		if got := x.ByByte(w.Byte); got != w {
+			t.Fatalf("ByByte(%d) = %+v, want %+v", w.Byte, got, w)
+		}
+		if k > 0 && want[k-1].Rune == w.Rune {
+			continue
+		}
+		if got := x.ByRune(w.Rune); got != w {
+			t.Fatalf("ByRune(%d) = %+v, want %+v", w.Rune, got, w)
+		}
+		if k+1 < len(want) {
+			// inside the sequence
+			for b := w.Byte + 1; b < want[k+1].Byte; b++ {
+				if got := x.ByByte(b); got != w {
+					t.Fatalf("ByByte(%d) = %+v, want %+v", b, got, w)
+				}
+			}
+		}
+	}
+
+	if got := x.End(); got != end {
+		t.Errorf("End() = %+v, want %+v", got, end)
+	}
+	if got := x.ByChar(1 << 20); got != end {
+		t.Errorf("ByChar(past end) = %+v, want %+v", got, end)
+	}
+	if got := x.ByRune(-1); got != (Position{}) {
+		t.Errorf("ByRune(-1) = %+v", got)
+	}
+}
+
+func TestIndexMalformed(t *testing.T) {
+	// each malformed sequence is one rune
+	x := NewIndex([]byte{'a', 0xff, 0xed, 0xa0, 0xbd, 'b', 0xe6})
+	tests := []struct {
+		rune int
+		want Position
+	}{
+		{1, Position{1, 1, 1}},
+		{2, Position{2, 2, 2}},
+		{3, Position{5, 3, 3}},
+		{4, Position{6, 4, 4}},
+		{5, Position{7, 5, 5}},
+	}
+	for _, tt := range tests {
+		if got := x.ByRune(tt.rune); got != tt.want {
+			t.Errorf("ByRune(%d) = %+v, want %+v", tt.rune, got, tt.want)
+		}
+	}
+}
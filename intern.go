@@ -0,0 +1,63 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "sync"
+
+// An Interner decodes like Decode, but returns the same string for input it
+// has seen before, so that duplicates share memory. Only input that decodes
+// without error is cached. An Interner is safe for concurrent use.
+type Interner struct {
+	mu sync.Mutex
+	c  Codec
+	m  map[string]string // encoded to decoded
+}
+
+// NewInterner returns an Interner decoding with the given options.
+func NewInterner(opts ...Option) *Interner {
+	return &Interner{c: Codec{o: newOptions(opts)}}
+}
+
+// Get returns the decoding of b, from the cache if possible. Looking up b
+// does not allocate.
+func (in *Interner) Get(b []byte) (string, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if s, ok := in.m[string(b)]; ok {
+		return s, nil
+	}
+
+	d, err := in.c.DecodeBytes(b)
+	if err != nil {
+		return "", err
+	}
+
+	// most strings decode to themselves, then key and value are the same
+	key := string(b)
+	s := key
+	if string(d) != key {
+		s = string(d)
+	}
+
+	if in.m == nil {
+		in.m = make(map[string]string)
+	}
+	in.m[key] = s
+	return s, nil
+}
+
+// Len returns the number of distinct strings cached.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.m)
+}
+
+// Reset empties the cache.
+func (in *Interner) Reset() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.m = nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("java/lang/Object"), "java/lang/Object"},
+		{Encode("a\x00\U0001f4a9"), "a\x00\U0001f4a9"},
+		{[]byte(""), ""},
+	}
+	for _, tt := range tests {
+		first, err := in.Get(tt.data)
+		if err != nil || first != tt.want {
+			t.Fatalf("Get(%q) = %q, %v; want %q", tt.data, first, err, tt.want)
+		}
+		second, _ := in.Get(append([]byte(nil), tt.data...))
+		if second != tt.want || len(first) > 0 && unsafe.StringData(first) != unsafe.StringData(second) {
+			t.Errorf("Get(%q) returned a new string", tt.data)
+		}
+	}
+	if in.Len() != len(tests) {
+		t.Errorf("Len() = %d, want %d", in.Len(), len(tests))
+	}
+
+	if _, err := in.Get([]byte{0xff}); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("Get(ff) error = %v", err)
+	}
+	if in.Len() != len(tests) {
+		t.Errorf("Len() = %d after error, want %d", in.Len(), len(tests))
+	}
+
+	in.Reset()
+	if in.Len() != 0 {
+		t.Errorf("Len() = %d after Reset", in.Len())
+	}
+}
+
+func TestInternerAllocs(t *testing.T) {
+	in := NewInterner()
+	b := Encode("java/lang/\x00String")
+	in.Get(b)
+	if n := testing.AllocsPerRun(10, func() { in.Get(b) }); n != 0 {
+		t.Errorf("Get allocates %v times for a cached string", n)
+	}
+}
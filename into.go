@@ -0,0 +1,59 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "errors"
+
+// ErrShortDst means that the destination buffer is too small for the output.
+var ErrShortDst = errors.New("destination buffer too short")
+
+// DecodeInto decodes src into dst like Decode, and returns the number of
+// bytes written. If dst is too small, nothing is written and ErrShortDst is
+// returned; len(src) bytes are always enough. Other errors are of type
+// *DecodeError.
+func DecodeInto(dst, src []byte) (int, error) {
+	if len(dst) < len(src) && len(dst) < DecodedLen(src) {
+		return 0, ErrShortDst
+	}
+
+	var o options
+	i := same(src, 0, len(src), &o)
+	out := append(dst[:0:len(dst)], src[:i]...)
+
+	out, err := decode(out, src, i, len(src), &o)
+	if err != nil {
+		return 0, err
+	}
+	return len(out), nil
+}
+
+// EncodeInto encodes s into dst like Encode, and returns the number of bytes
+// written. If dst is too small, nothing is written and ErrShortDst is
+// returned; EncodedLen(s) bytes are enough.
+func EncodeInto(dst []byte, s string) (int, error) {
+	if len(dst) < EncodedLen(s) {
+		return 0, ErrShortDst
+	}
+	return len(encode(dst[:0:len(dst)], s, &options{})), nil
+}
+
+// AppendEncode appends the encoding of s to dst and returns the extended
+// buffer. It only allocates if dst lacks capacity.
+func AppendEncode(dst []byte, s string) []byte {
+	return encode(dst, s, &options{})
+}
+
+// AppendDecode appends the decoding of src to dst and returns the extended
+// buffer. It only allocates if dst lacks capacity. Errors are of type
+// *DecodeError, in which case dst is returned unchanged.
+func AppendDecode(dst, src []byte) ([]byte, error) {
+	var o options
+	i := same(src, 0, len(src), &o)
+
+	out, err := decode(append(dst, src[:i]...), src, i, len(src), &o)
+	if err != nil {
+		return dst, err
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeInto(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		size int
+		want string
+		err  error
+	}{
+		{"UTF-8", []byte("a\x00b"), 3, "a\x00b", nil},
+		{"modified", Encode("a\x00\U0001f4a9"), 6, "a\x00\U0001f4a9", nil},
+		{"short", Encode("a\x00\U0001f4a9"), 5, "", ErrShortDst},
+		{"large", Encode("a\x00"), 100, "a\x00", nil},
+		{"invalid", []byte{'a', 0xff}, 2, "", ErrInvalidEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := make([]byte, tt.size)
+			n, err := DecodeInto(dst, tt.src)
+			if got := string(dst[:n]); got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("DecodeInto() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+
+	dst := make([]byte, 64)
+	src := Encode("Hello\x00Wörld!!! \U0001f4a9")
+	if n := testing.AllocsPerRun(100, func() { DecodeInto(dst, src) }); n != 0 {
+		t.Errorf("DecodeInto() allocates %v times", n)
+	}
+}
+
+func TestEncodeInto(t *testing.T) {
+	s := "Hello\x00Wörld!!! \U0001f4a9"
+	want := Encode(s)
+
+	dst := make([]byte, len(want))
+	if n, err := EncodeInto(dst, s); err != nil || string(dst[:n]) != string(want) {
+		t.Errorf("EncodeInto() = %q, %v; want %q", dst[:n], err, want)
+	}
+	if n, err := EncodeInto(dst[:len(want)-1], s); n != 0 || err != ErrShortDst {
+		t.Errorf("EncodeInto() = %d, %v; want ErrShortDst", n, err)
+	}
+	if n := testing.AllocsPerRun(100, func() { EncodeInto(dst, s) }); n != 0 {
+		t.Errorf("EncodeInto() allocates %v times", n)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	s := "Hello\x00Wörld!!! \U0001f4a9"
+	buf := make([]byte, 0, 64)
+
+	buf = AppendEncode(append(buf, "x"...), s)
+	if want := "x" + string(Encode(s)); string(buf) != want {
+		t.Errorf("AppendEncode() = %q, want %q", buf, want)
+	}
+
+	buf, err := AppendDecode(buf[:1], Encode(s))
+	if err != nil || string(buf) != "x"+s {
+		t.Errorf("AppendDecode() = %q, %v; want %q", buf, err, "x"+s)
+	}
+	if got, err := AppendDecode(buf[:1], []byte{'a', 0xff}); err == nil || string(got) != "x" {
+		t.Errorf("AppendDecode() = %q, %v; want error", got, err)
+	}
+
+	d := Encode(s)
+	if n := testing.AllocsPerRun(100, func() {
+		buf = AppendEncode(buf[:0], s)
+		buf, _ = AppendDecode(buf[:0], d)
+	}); n != 0 {
+		t.Errorf("AppendEncode(), AppendDecode() allocate %v times", n)
+	}
+}
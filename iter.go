@@ -0,0 +1,173 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"unicode/utf8"
+)
+
+// Runes returns an iterator over the runes of b and their byte offsets, like
+// ranging over a string: malformed sequences give U+FFFD. Raw NULs and
+// 4-byte sequences are decoded as well.
+func Runes(b []byte) iter.Seq2[int, rune] {
+	return func(yield func(int, rune) bool) {
+		for i := 0; i < len(b); {
+			r, n := DecodeRune(b[i:])
+			if !yield(i, r) {
+				return
+			}
+			i += n
+		}
+	}
+}
+
+// RunesErr is like Runes, but stops at the first malformed sequence. The
+// returned function reports it as a *DecodeError once the loop is done.
+func RunesErr(b []byte) (iter.Seq2[int, rune], func() error) {
+	var err error
+	seq := func(yield func(int, rune) bool) {
+		err = nil
+		for i := 0; i < len(b); {
+			r, n := DecodeRune(b[i:])
+			if r == utf8.RuneError {
+				if _, serr := scan(b[i:]); serr != nil {
+					err = newDecodeError(b, i, serr)
+					return
+				}
+			}
+			if !yield(i, r) {
+				return
+			}
+			i += n
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// RunesBackward returns an iterator over the runes of b and their byte
+// offsets, from the last to the first, using DecodeLastRune.
+func RunesBackward(b []byte) iter.Seq2[int, rune] {
+	return func(yield func(int, rune) bool) {
+		for i := len(b); i > 0; {
+			r, n := DecodeLastRune(b[:i])
+			i -= n
+			if !yield(i, r) {
+				return
+			}
+		}
+	}
+}
+
+// Segments returns an iterator over the decoding of b in pieces: the longest
+// runs of b that are already standard UTF-8, which are subslices of b, and
+// the decodings of the modified sequences between them. Malformed sequences
+// give U+FFFD. Together the pieces are what a Decoder using Lossy returns,
+// without building it. The pieces must not be modified, and the decoded ones
+// are only valid until the next iteration.
+func Segments(b []byte) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		var tmp [utf8.UTFMax]byte
+		start := 0
+		for i := 0; i < len(b); {
+			if i += asciiSpan(b[i:]); i == len(b) {
+				break
+			}
+
+			n, err := scan(b[i:])
+			if err == nil && n < 6 && b[i] != 0xc0 || err == ErrInvalidNUL || err == ErrFourByte {
+				// standard UTF-8
+				i += n
+				continue
+			}
+
+			if start < i && !yield(b[start:i:i]) {
+				return
+			}
+			r, _ := DecodeRune(b[i : i+n])
+			if !yield(tmp[:utf8.EncodeRune(tmp[:], r)]) {
+				return
+			}
+			i += n
+			start = i
+		}
+
+		if start < len(b) {
+			yield(b[start:])
+		}
+	}
+}
+
+// DecodeResilient returns an iterator that decodes b past malformed input,
+// for recovering what can be from damaged data. It yields the longest
+// decodable chunks of b with a nil error, and a *DecodeError with an empty
+// string for each malformed sequence between them, then carries on after it.
+// Raw NULs and 4-byte sequences are decoded, as by a Decoder.
+func DecodeResilient(b []byte) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		o := options{std: true}
+		var buf []byte
+		for i := 0; i < len(b); {
+			n := asciiSpan(b[i:])
+			buf = append(buf, b[i:i+n]...)
+			if i += n; i == len(b) {
+				break
+			}
+
+			n, err := scan(b[i:])
+			if buf, err = decodeSeq(buf, b[i:i+n], err, &o); err != nil {
+				if len(buf) > 0 && !yield(string(buf), nil) {
+					return
+				}
+				if !yield("", newDecodeError(b, i, err)) {
+					return
+				}
+				buf = buf[:0]
+			}
+			i += n
+		}
+
+		if len(buf) > 0 {
+			yield(string(buf), nil)
+		}
+	}
+}
+
+// Lines returns an iterator over the lines of encoded data read from r,
+// decoded by a Decoder with the given options. Lines are split on "\n",
+// which is not included, nor is a "\r" before it. Lines longer than
+// bufio.MaxScanTokenSize are reported as bufio.ErrTooLong, so memory use is
+// bounded. An error ends the iteration, and the line it was found in is not
+// returned.
+func Lines(r io.Reader, opts ...Option) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		br := bufio.NewReaderSize(NewDecoder(r, opts...), bufio.MaxScanTokenSize)
+		for {
+			line, err := br.ReadSlice('\n')
+			switch {
+			case err == bufio.ErrBufferFull:
+				err = bufio.ErrTooLong
+			case err == nil:
+				line = line[:len(line)-1]
+			case err == io.EOF && len(line) > 0:
+				err = nil
+			}
+			if err != nil {
+				if err != io.EOF {
+					yield("", err)
+				}
+				return
+			}
+
+			if n := len(line); n > 0 && line[n-1] == '\r' {
+				line = line[:n-1]
+			}
+			if !yield(string(line), nil) {
+				return
+			}
+		}
+	}
+}
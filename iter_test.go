@@ -0,0 +1,221 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type offsetRune struct {
+	i int
+	r rune
+}
+
+func TestRunes(t *testing.T) {
+	b := []byte{'a', 0xc0, 0x80, 0xc3, 0xa9, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9, 0, 0xff, 'b'}
+	want := []offsetRune{{0, 'a'}, {1, 0}, {3, 'é'}, {5, 0x1f4a9}, {11, 0}, {12, 0xfffd}, {13, 'b'}}
+
+	var got []offsetRune
+	for i, r := range Runes(b) {
+		got = append(got, offsetRune{i, r})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Runes() = %v, want %v", got, want)
+	}
+	for k := range got {
+		if got[k] != want[k] {
+			t.Errorf("Runes() = %v, want %v", got, want)
+			break
+		}
+	}
+
+	// stopping early
+	for i := range Runes(b) {
+		if i > 0 {
+			break
+		}
+	}
+}
+
+func TestRunesErr(t *testing.T) {
+	seq, errFn := RunesErr([]byte{'a', 0xc0, 0x80, 0xff, 'b'})
+	var got []rune
+	for _, r := range seq {
+		got = append(got, r)
+	}
+
+	var de *DecodeError
+	if string(got) != "a\x00" {
+		t.Errorf("RunesErr() = %q", string(got))
+	}
+	if err := errFn(); !errors.As(err, &de) || de.Offset != 3 || de.Err != ErrInvalidEncoding {
+		t.Errorf("RunesErr() error = %v", err)
+	}
+
+	seq, errFn = RunesErr(Encode("ok�"))
+	n := 0
+	for range seq {
+		n++
+	}
+	if n != 3 || errFn() != nil {
+		t.Errorf("RunesErr(U+FFFD) = %d runes, %v", n, errFn())
+	}
+}
+
+func TestRunesBackward(t *testing.T) {
+	b := Encode("Outer$Inner\x00\U0001f4a9$1")
+	var want []offsetRune
+	for i, r := range Runes(b) {
+		want = append([]offsetRune{{i, r}}, want...)
+	}
+
+	var got []offsetRune
+	for i, r := range RunesBackward(b) {
+		got = append(got, offsetRune{i, r})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("RunesBackward() = %v, want %v", got, want)
+	}
+	for k := range got {
+		if got[k] != want[k] {
+			t.Errorf("RunesBackward() = %v, want %v", got, want)
+			break
+		}
+	}
+
+	// stripping a suffix
+	end := len(b)
+	for i, r := range RunesBackward(b) {
+		if r == '$' {
+			end = i
+			break
+		}
+	}
+	if s, _ := Decode(b[:end]); s != "Outer$Inner\x00\U0001f4a9" {
+		t.Errorf("stripped suffix = %q", s)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		[]byte("plain"),
+		Encode("a\x00b\U0001f4a9c"),
+		append([]byte("std\x00\U0001f4a9"), Encode("mod\x00\U0001f4a9")...),
+		{'a', 0xff, 0xed, 0xa0, 0xbd, 'b', 0xe6},
+	}
+	for _, b := range tests {
+		want, err := io.ReadAll(NewDecoder(bytes.NewReader(b), Lossy()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []byte
+		for seg := range Segments(b) {
+			got = append(got, seg...)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Segments(%x) = %q, want %q", b, got, want)
+		}
+	}
+
+	// plain runs are not copied
+	b := Encode("abc\x00def")
+	for seg := range Segments(b) {
+		if &seg[0] != &b[0] {
+			t.Errorf("Segments() copied %q", seg)
+		}
+		break
+	}
+}
+
+func TestDecodeResilient(t *testing.T) {
+	type part struct {
+		s      string
+		offset int // of the error, or -1
+		err    error
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want []part
+	}{
+		{"empty", nil, nil},
+		{"valid", Encode("a\x00\U0001f4a9"), []part{{"a\x00\U0001f4a9", -1, nil}}},
+		{"standard", []byte("a\x00\U0001f4a9"), []part{{"a\x00\U0001f4a9", -1, nil}}},
+		{"damage", []byte{'a', 0xc0, 0x80, 0xff, 0xfe, 'b', 0xed, 0xa0, 0xbd, 'c', 0xe6},
+			[]part{
+				{"a\x00", -1, nil},
+				{"", 3, ErrInvalidEncoding},
+				{"", 4, ErrInvalidEncoding},
+				{"b", -1, nil},
+				{"", 6, ErrUnpairedSurrogate},
+				{"c", -1, nil},
+				{"", 10, ErrTooShort},
+			}},
+		{"leading", []byte{0x80, 'x'}, []part{{"", 0, ErrInvalidEncoding}, {"x", -1, nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []part
+			for s, err := range DecodeResilient(tt.data) {
+				p := part{s, -1, err}
+				var de *DecodeError
+				if errors.As(err, &de) {
+					p.offset, p.err = de.Offset, de.Err
+				}
+				got = append(got, p)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("DecodeResilient() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("DecodeResilient() part %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	for range DecodeResilient([]byte{'a', 0xff, 'b'}) {
+		break
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want []string
+		err  error
+	}{
+		{nil, nil, nil},
+		{Encode("one\ntwo\x00\r\n\nthree\U0001f4a9"), []string{"one", "two\x00", "", "three\U0001f4a9"}, nil},
+		{Encode("one\n"), []string{"one"}, nil},
+		{[]byte{'a', '\n', 'b', 0xff, '\n'}, []string{"a"}, ErrInvalidEncoding},
+		{Encode("a\n" + strings.Repeat("x", bufio.MaxScanTokenSize+1)), []string{"a"}, bufio.ErrTooLong},
+	}
+	for _, tt := range tests {
+		var got []string
+		var err error
+		for line, lerr := range Lines(bytes.NewReader(tt.data)) {
+			if lerr != nil {
+				err = lerr
+				break
+			}
+			got = append(got, line)
+		}
+
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("Lines(%.20q) = %q, want %q", tt.data, got, tt.want)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Lines(%.20q) error = %v, want %v", tt.data, err, tt.err)
+		}
+	}
+}
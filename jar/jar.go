@@ -0,0 +1,73 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package jar reads the strings of the classes in jar files, for analyses
+// over many of them.
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/anders/jutf"
+)
+
+// ClassStrings returns an iterator over the .class entries of the jar or zip
+// file r of the given size, yielding the name of each class, such as
+// "com/example/Foo", with the strings of its CONSTANT_Utf8 entries in
+// constant pool order. Malformed strings are decoded with U+FFFD in place
+// of the bad sequences. The iterator stops at the first entry that cannot be
+// read or parsed; the returned function reports the error once the loop is
+// done.
+func ClassStrings(r io.ReaderAt, size int64) (iter.Seq2[string, []string], func() error) {
+	var err error
+	seq := func(yield func(string, []string) bool) {
+		var zr *zip.Reader
+		if zr, err = zip.NewReader(r, size); err != nil {
+			return
+		}
+
+		var b []byte
+		for _, f := range zr.File {
+			name, ok := strings.CutSuffix(f.Name, ".class")
+			if !ok || f.FileInfo().IsDir() {
+				continue
+			}
+			if b, err = readZipFile(b[:0], f); err != nil {
+				err = fmt.Errorf("jar: %s: %w", f.Name, err)
+				return
+			}
+
+			var ds [][]byte
+			if ds, err = jutf.ClassStrings(b); err != nil {
+				err = fmt.Errorf("jar: %s: %w", f.Name, err)
+				return
+			}
+			strs := make([]string, len(ds))
+			for k, d := range ds {
+				strs[k], _ = jutf.Decode(d, jutf.Lossy())
+			}
+			if !yield(name, strs) {
+				return
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// readZipFile appends the contents of f to b.
+func readZipFile(b []byte, f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return b, err
+	}
+	defer rc.Close()
+
+	buf := bytes.NewBuffer(b)
+	_, err = buf.ReadFrom(rc)
+	return buf.Bytes(), err
+}
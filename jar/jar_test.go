@@ -0,0 +1,103 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anders/jutf"
+)
+
+// testClass returns the start of a class file with the given constant pool
+// entries, each a tag followed by the body.
+func testClass(count int, entries ...[]byte) []byte {
+	b := []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52}
+	b = binary.BigEndian.AppendUint16(b, uint16(count))
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	return b
+}
+
+// testUtf8Entry returns a CONSTANT_Utf8 entry holding d.
+func testUtf8Entry(d []byte) []byte {
+	b := binary.BigEndian.AppendUint16([]byte{1}, uint16(len(d)))
+	return append(b, d...)
+}
+
+// testJar returns a zip file with the given names and contents.
+func testJar(files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, _ := zw.Create(files[i])
+		w.Write([]byte(files[i+1]))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestClassStrings(t *testing.T) {
+	foo := testClass(4,
+		testUtf8Entry([]byte("com/example/Foo")),
+		[]byte{7, 0, 1},
+		testUtf8Entry(jutf.Encode("a\x00\U0001f4a9")),
+	)
+	bar := testClass(2, testUtf8Entry([]byte("bad \xff")))
+	jar := testJar(
+		"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n",
+		"com/example/", "",
+		"com/example/Foo.class", string(foo),
+		"com/example/Bar.class", string(bar),
+	)
+
+	var names []string
+	var strs [][]string
+	seq, errf := ClassStrings(bytes.NewReader(jar), int64(len(jar)))
+	for name, ss := range seq {
+		names = append(names, name)
+		strs = append(strs, ss)
+	}
+	if err := errf(); err != nil {
+		t.Fatalf("ClassStrings error = %v", err)
+	}
+	if want := []string{"com/example/Foo", "com/example/Bar"}; !slices.Equal(names, want) {
+		t.Errorf("ClassStrings names = %q, want %q", names, want)
+	}
+	want := [][]string{{"com/example/Foo", "a\x00\U0001f4a9"}, {"bad \ufffd"}}
+	if !slices.EqualFunc(strs, want, slices.Equal) {
+		t.Errorf("ClassStrings strings = %q, want %q", strs, want)
+	}
+
+	// stopping early
+	for name := range seq {
+		if name != "com/example/Foo" {
+			t.Errorf("ClassStrings first class = %q", name)
+		}
+		break
+	}
+
+	bad := testJar("Foo.class", string(foo), "Bad.class", "\xca\xfe\xba\xbe", "Bar.class", string(bar))
+	n := 0
+	seq, errf = ClassStrings(bytes.NewReader(bad), int64(len(bad)))
+	for range seq {
+		n++
+	}
+	if err := errf(); n != 1 || err == nil || !strings.Contains(err.Error(), "Bad.class") {
+		t.Errorf("ClassStrings(bad class) = %d classes, %v", n, err)
+	}
+
+	seq, errf = ClassStrings(bytes.NewReader(foo), int64(len(foo)))
+	for range seq {
+		t.Errorf("ClassStrings(not a zip) yielded a class")
+	}
+	if errf() == nil {
+		t.Errorf("ClassStrings(not a zip) succeeded")
+	}
+}
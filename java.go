@@ -0,0 +1,292 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Exception identifies the java.io exception thrown by
+// DataInputStream.readUTF.
+type Exception int
+
+// readUTF throws UTFDataFormatException for malformed data and EOFException
+// when the stream ends before the length prefix or the data is complete.
+const (
+	UTFDataFormatException Exception = iota + 1
+	EOFException
+)
+
+func (e Exception) String() string {
+	switch e {
+	case UTFDataFormatException:
+		return "java.io.UTFDataFormatException"
+	case EOFException:
+		return "java.io.EOFException"
+	}
+	return "Exception(" + strconv.Itoa(int(e)) + ")"
+}
+
+// A JavaError is returned by DecodeJava and ReadUTF for the conditions in
+// which the JVM would throw. Exception and Msg match what Java reports.
+type JavaError struct {
+	Exception Exception
+	Msg       string // exception message, empty for EOFException
+	Offset    int    // offset reported in Msg, or -1 if none
+	Err       error  // io.EOF or io.ErrUnexpectedEOF for EOFException
+}
+
+func (e *JavaError) Error() string {
+	if e.Msg == "" {
+		return e.Exception.String()
+	}
+	return e.Exception.String() + ": " + e.Msg
+}
+
+func (e *JavaError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeJava decodes d exactly the way DataInputStream.readUTF does. Unlike
+// Decode it accepts raw NUL bytes, overlong forms and unpaired surrogates;
+// the latter become U+FFFD in the result since Go strings cannot hold them.
+// Errors are of type *JavaError.
+func DecodeJava(d []byte) (string, error) {
+	chars, err := decodeJava(d)
+	if err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// DecodeToUTF16 decodes d to the chars of the String that
+// DataInputStream.readUTF returns for it. Like Java, and unlike ToUTF16, it
+// accepts overlong forms and does not check that surrogates are paired; it
+// fails with a *JavaError where Java throws.
+func DecodeToUTF16(d []byte) ([]uint16, error) {
+	return decodeJava(d)
+}
+
+// decodeJava is a transcription of the loop in DataInputStream.readUTF.
+func decodeJava(d []byte) ([]uint16, error) {
+	chars := make([]uint16, 0, len(d))
+
+	for count := 0; count < len(d); {
+		c := int(d[count])
+		switch c >> 4 {
+		case 0, 1, 2, 3, 4, 5, 6, 7:
+			// 0xxxxxxx
+			count++
+			chars = append(chars, uint16(c))
+		case 12, 13:
+			// 110x xxxx 10xx xxxx
+			count += 2
+			if count > len(d) {
+				return nil, errPartialChar
+			}
+			c2 := int(d[count-1])
+			if c2&0xc0 != 0x80 {
+				return nil, malformedAround(count)
+			}
+			chars = append(chars, uint16((c&0x1f)<<6|c2&0x3f))
+		case 14:
+			// 1110 xxxx 10xx xxxx 10xx xxxx
+			count += 3
+			if count > len(d) {
+				return nil, errPartialChar
+			}
+			c2, c3 := int(d[count-2]), int(d[count-1])
+			if c2&0xc0 != 0x80 || c3&0xc0 != 0x80 {
+				return nil, malformedAround(count - 1)
+			}
+			chars = append(chars, uint16((c&0xf)<<12|(c2&0x3f)<<6|c3&0x3f))
+		default:
+			// 10xx xxxx, 1111 xxxx
+			return nil, malformedAround(count)
+		}
+	}
+
+	return chars, nil
+}
+
+var errPartialChar = &JavaError{
+	Exception: UTFDataFormatException,
+	Msg:       "malformed input: partial character at end",
+	Offset:    -1,
+}
+
+func malformedAround(offset int) error {
+	return &JavaError{
+		Exception: UTFDataFormatException,
+		Msg:       "malformed input around byte " + strconv.Itoa(offset),
+		Offset:    offset,
+	}
+}
+
+// ReadUTF reads a string written by DataOutput.writeUTF: a big-endian
+// 16-bit length followed by that many bytes, decoded with DecodeJava.
+// Errors are of type *JavaError, except for MaxLen: a length over it is
+// reported as ErrTooLarge in a *DecodeError, before the data is read or
+// memory allocated for it. Other options do not apply.
+func ReadUTF(r io.Reader, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	d, err := readFrame(nil, r, o.maxLen)
+	if err != nil {
+		return "", err
+	}
+	return DecodeJava(d)
+}
+
+// readFrame appends the data of a frame written by writeUTF to b. If max is
+// positive, longer frames are an error.
+func readFrame(b []byte, r io.Reader, max int) ([]byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return b, eofError(err)
+	}
+
+	n := int(binary.BigEndian.Uint16(hdr[:]))
+	if max > 0 && len(b)+n > max {
+		return b, newDecodeError(nil, max, ErrTooLarge)
+	}
+	b = slices.Grow(b, n)
+	if _, err := io.ReadFull(r, b[len(b):len(b)+n]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return b, eofError(err)
+	}
+	return b[:len(b)+n], nil
+}
+
+// ReadUTFFrom is like ReadUTF, but decodes the string directly from the
+// buffer of br when it fits, so that only the result is allocated. Longer
+// strings are read as by ReadUTF.
+func ReadUTFFrom(br *bufio.Reader, opts ...Option) (string, error) {
+	hdr, err := br.Peek(2)
+	if err != nil {
+		br.Discard(len(hdr))
+		if len(hdr) > 0 && err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", eofError(err)
+	}
+
+	o := newOptions(opts)
+	n := 2 + int(binary.BigEndian.Uint16(hdr))
+	if n > br.Size() || o.maxLen > 0 && n-2 > o.maxLen {
+		return ReadUTF(br, opts...)
+	}
+
+	d, err := br.Peek(n)
+	br.Discard(len(d))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", eofError(err)
+	}
+
+	// canonical data decodes the same either way, and Decode only
+	// allocates the result
+	if d = d[2:]; Valid(d) {
+		return Decode(d)
+	}
+	return DecodeJava(d)
+}
+
+// eofError converts the EOF errors from io.ReadFull, passing others through.
+func eofError(err error) error {
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return &JavaError{Exception: EOFException, Offset: -1, Err: err}
+}
+
+// maxUTF is the most data DataOutput.writeUTF can write after the length.
+const maxUTF = 0xffff
+
+// WriteUTF writes s the way DataOutput.writeUTF does: a big-endian 16-bit
+// length followed by the encoding of s. Like Java, it fails with a
+// *JavaError if that is longer than 65535 bytes.
+func WriteUTF(w io.Writer, s string) error {
+	n := EncodedLen(s)
+	if n > maxUTF {
+		return tooLongError(n)
+	}
+
+	buf := make([]byte, 2, 2+n)
+	binary.BigEndian.PutUint16(buf, uint16(n))
+	_, err := w.Write(encode(buf, s, &options{}))
+	return err
+}
+
+func tooLongError(n int) error {
+	return &JavaError{
+		Exception: UTFDataFormatException,
+		Msg:       "encoded string too long: " + strconv.Itoa(n) + " bytes",
+		Offset:    -1,
+	}
+}
+
+// chunkFull is the length from which a frame written by WriteUTFChunked is
+// continued by the next one: it is only shorter than 65535 bytes when the
+// next char would not have fit.
+const chunkFull = maxUTF - 2
+
+// WriteUTFChunked writes s like WriteUTF, but splits an encoding longer than
+// 65535 bytes into consecutive frames, for protocols that extend writeUTF
+// that way. Each frame is as long as it can be without splitting a char, so
+// every frame of at least 65533 bytes is followed by another, if need be an
+// empty one. A Java reader may call readUTF on each and concatenate them.
+func WriteUTFChunked(w io.Writer, s string) error {
+	d := Encode(s)
+	buf := make([]byte, 0, 2+min(len(d), maxUTF))
+	for {
+		n := len(d)
+		if n > maxUTF {
+			// surrogate pairs may be split, as Java would
+			n = maxUTF
+			for d[n]&0xc0 == 0x80 {
+				n--
+			}
+		}
+
+		buf = binary.BigEndian.AppendUint16(buf[:0], uint16(n))
+		if _, err := w.Write(append(buf, d[:n]...)); err != nil {
+			return err
+		}
+		if d = d[n:]; n < chunkFull {
+			return nil
+		}
+	}
+}
+
+// ReadUTFChunked reads a string written by WriteUTFChunked, joining frames
+// until one is shorter than 65533 bytes, and decodes it as ReadUTF does.
+// MaxLen limits the length of all the frames together.
+func ReadUTFChunked(r io.Reader, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	var d []byte
+	for {
+		start := len(d)
+		var err error
+		if d, err = readFrame(d, r, o.maxLen); err != nil {
+			if start > 0 && errors.Is(err, io.EOF) {
+				// the string was cut off between frames
+				err = eofError(io.ErrUnexpectedEOF)
+			}
+			return "", err
+		}
+		if len(d)-start < chunkFull {
+			return DecodeJava(d)
+		}
+	}
+}
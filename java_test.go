@@ -0,0 +1,286 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeJava(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		err  string
+	}{
+		{"NULL", []byte{0xc0, 0x80}, "\x00", ""},
+		{"raw NUL", []byte{'a', 0, 'b'}, "a\x00b", ""},
+		{"surrogate pair", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, "\U0001f4a9", ""},
+		{"lone surrogate", []byte{0xed, 0xa0, 0xbd}, "�", ""},
+		{"partial 2", []byte{'a', 0xc3}, "", "java.io.UTFDataFormatException: malformed input: partial character at end"},
+		{"partial 3", []byte{0xe6, 0x97}, "", "java.io.UTFDataFormatException: malformed input: partial character at end"},
+		{"bad 2", []byte{'a', 0xc3, 'b'}, "", "java.io.UTFDataFormatException: malformed input around byte 3"},
+		{"bad 3", []byte{0xe6, 0x97, 'b'}, "", "java.io.UTFDataFormatException: malformed input around byte 2"},
+		{"continuation", []byte{'a', 'b', 0x80}, "", "java.io.UTFDataFormatException: malformed input around byte 2"},
+		{"four byte", []byte("\U0001f4a9"), "", "java.io.UTFDataFormatException: malformed input around byte 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJava(tt.data)
+			if got != tt.want {
+				t.Errorf("DecodeJava() = %q, want %q", got, tt.want)
+			}
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("DecodeJava() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeToUTF16(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []uint16
+		err  string
+	}{
+		{"empty", []byte{}, []uint16{}, ""},
+		{"NULs", []byte{0, 0xc0, 0x80}, []uint16{0, 0}, ""},
+		{"pair", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, []uint16{0xd83d, 0xdca9}, ""},
+		{"reversed pair", []byte{0xed, 0xb2, 0xa9, 0xed, 0xa0, 0xbd}, []uint16{0xdca9, 0xd83d}, ""},
+		{"overlong", []byte{0xc1, 0x81, 0xe0, 0x80, 0x80}, []uint16{'A', 0}, ""},
+		{"four byte", []byte("\U0001f4a9"), nil, "java.io.UTFDataFormatException: malformed input around byte 0"},
+		{"partial", []byte{'a', 0xe6, 0x97}, nil, "java.io.UTFDataFormatException: malformed input: partial character at end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeToUTF16(tt.data)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeToUTF16() = %x, want %x", got, tt.want)
+			}
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("DecodeToUTF16() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReadUTF(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		exc  Exception
+		err  error
+	}{
+		{"empty string", []byte{0, 0}, "", 0, nil},
+		{"string", []byte{0, 3, 'a', 0xc0, 0x80}, "a\x00", 0, nil},
+		{"no length", []byte{}, "", EOFException, io.EOF},
+		{"short length", []byte{0}, "", EOFException, io.ErrUnexpectedEOF},
+		{"no data", []byte{0, 1}, "", EOFException, io.ErrUnexpectedEOF},
+		{"short data", []byte{0, 3, 'a'}, "", EOFException, io.ErrUnexpectedEOF},
+		{"malformed", []byte{0, 1, 0xff}, "", UTFDataFormatException, nil},
+	}
+	readers := []struct {
+		name string
+		read func([]byte) (string, error)
+	}{
+		{"ReadUTF", func(d []byte) (string, error) { return ReadUTF(bytes.NewReader(d)) }},
+		{"ReadUTFFrom", func(d []byte) (string, error) { return ReadUTFFrom(bufio.NewReader(bytes.NewReader(d))) }},
+	}
+	for _, tt := range tests {
+		for _, r := range readers {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := r.read(tt.data)
+				if got != tt.want {
+					t.Errorf("%s() = %q, want %q", r.name, got, tt.want)
+				}
+
+				var je *JavaError
+				if tt.exc == 0 {
+					if err != nil {
+						t.Errorf("%s() error = %v", r.name, err)
+					}
+				} else if !errors.As(err, &je) || je.Exception != tt.exc || tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("%s() error = %v, want %v (%v)", r.name, err, tt.exc, tt.err)
+				}
+			})
+		}
+	}
+}
+
+func TestReadUTFReaderError(t *testing.T) {
+	readers := []struct {
+		name string
+		read func(io.Reader) (string, error)
+	}{
+		{"ReadUTF", func(r io.Reader) (string, error) { return ReadUTF(r) }},
+		{"ReadUTFFrom", func(r io.Reader) (string, error) { return ReadUTFFrom(bufio.NewReader(r)) }},
+	}
+	for _, rd := range readers {
+		// the error comes after the first byte of the length
+		r := iotest.TimeoutReader(iotest.OneByteReader(bytes.NewReader([]byte{0, 1, 'a'})))
+		if _, err := rd.read(r); err != iotest.ErrTimeout {
+			t.Errorf("%s() error = %v, want %v", rd.name, err, iotest.ErrTimeout)
+		}
+	}
+}
+
+func TestReadUTFFrom(t *testing.T) {
+	var buf bytes.Buffer
+	long := strings.Repeat("x\x00", 10000)
+	for _, s := range []string{"abc", "a\x00\U0001f4a9", long, "", "end"} {
+		WriteUTF(&buf, s)
+	}
+	buf.Write([]byte{0, 3, 0xed, 0xa0, 0xbd})
+
+	br := bufio.NewReaderSize(&buf, 64)
+	for _, want := range []string{"abc", "a\x00\U0001f4a9", long, "", "end", "\ufffd"} {
+		if got, err := ReadUTFFrom(br); got != want || err != nil {
+			t.Fatalf("ReadUTFFrom() = %.20q, %v; want %.20q", got, err, want)
+		}
+	}
+	if _, err := ReadUTFFrom(br); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadUTFFrom() at end error = %v, want EOF", err)
+	}
+
+	d := []byte{0, 3, 'a', 0xc0, 0x80}
+	br = bufio.NewReader(bytes.NewReader(bytes.Repeat(d, 100)))
+	br.Peek(1)
+	if n := testing.AllocsPerRun(50, func() { ReadUTFFrom(br) }); n != 1 {
+		t.Errorf("ReadUTFFrom() allocates %v times, want 1", n)
+	}
+}
+
+func TestReadUTFMaxLen(t *testing.T) {
+	frame := []byte{0xff, 0xff, 'a'}
+	readers := []struct {
+		name string
+		read func(io.Reader, ...Option) (string, error)
+	}{
+		{"ReadUTF", ReadUTF},
+		{"ReadUTFFrom", func(r io.Reader, opts ...Option) (string, error) { return ReadUTFFrom(bufio.NewReader(r), opts...) }},
+		{"ReadUTFChunked", ReadUTFChunked},
+	}
+	for _, rd := range readers {
+		r := &countReader{r: bytes.NewReader(frame)}
+		_, err := rd.read(r, MaxLen(1000))
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Err != ErrTooLarge || de.Offset != 1000 {
+			t.Errorf("%s(MaxLen(1000)) error = %v", rd.name, err)
+		}
+		if r.n > 3 {
+			t.Errorf("%s(MaxLen(1000)) read %d bytes", rd.name, r.n)
+		}
+
+		if s, err := rd.read(bytes.NewReader([]byte{0, 3, 'a', 'b', 'c'}), MaxLen(3)); s != "abc" || err != nil {
+			t.Errorf("%s(MaxLen(3)) = %q, %v", rd.name, s, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	WriteUTFChunked(&buf, strings.Repeat("x", 70000))
+	if _, err := ReadUTFChunked(&buf, MaxLen(69999)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("ReadUTFChunked(MaxLen(69999)) error = %v", err)
+	}
+}
+
+// countReader counts the bytes read through it.
+type countReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestWriteUTF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteUTF(&buf, "a\x00\U0001f4a9"); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := ReadUTF(&buf); err != nil || s != "a\x00\U0001f4a9" {
+		t.Errorf("ReadUTF(WriteUTF()) = %q, %v", s, err)
+	}
+
+	var je *JavaError
+	err := WriteUTF(&buf, strings.Repeat("\x00", 40000))
+	if !errors.As(err, &je) || je.Error() != "java.io.UTFDataFormatException: encoded string too long: 80000 bytes" {
+		t.Errorf("WriteUTF(too long) error = %v", err)
+	}
+}
+
+func TestUTFChunked(t *testing.T) {
+	tests := []struct {
+		name   string
+		str    string
+		frames int
+	}{
+		{"empty", "", 1},
+		{"short", "a\x00b", 1},
+		{"under full", strings.Repeat("x", chunkFull-1), 1},
+		{"full", strings.Repeat("x", chunkFull), 2},
+		{"max", strings.Repeat("x", maxUTF), 2},
+		{"over max", strings.Repeat("x", maxUTF+1), 2},
+		{"split pair", strings.Repeat("x", maxUTF-3) + "\U0001f4a9", 2},
+		{"long", strings.Repeat("ab\x00\u65e5\U0001f4a9", 20000), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteUTFChunked(&buf, tt.str); err != nil {
+				t.Fatal(err)
+			}
+			buf.WriteString("\x00\x01z")
+
+			// each frame can be read on its own, as Java would
+			r := bytes.NewReader(buf.Bytes())
+			var chars []uint16
+			for n := 0; n < tt.frames; n++ {
+				d, err := readFrame(nil, r, 0)
+				if err != nil {
+					t.Fatalf("frame %d: %v", n, err)
+				}
+				c, err := DecodeToUTF16(d)
+				if err != nil {
+					t.Fatalf("frame %d: %v", n, err)
+				}
+				chars = append(chars, c...)
+			}
+			if want, _ := DecodeToUTF16(Encode(tt.str)); !slices.Equal(chars, want) {
+				t.Errorf("frames decode to %d chars, want %d", len(chars), len(want))
+			}
+			if s, _ := ReadUTF(r); s != "z" {
+				t.Errorf("%d frames written, want %d", tt.frames+1, tt.frames)
+			}
+
+			got, err := ReadUTFChunked(&buf)
+			if got != tt.str || err != nil {
+				t.Errorf("ReadUTFChunked() = %.20q, %v; want %.20q", got, err, tt.str)
+			}
+			if got, _ := ReadUTF(&buf); got != "z" {
+				t.Errorf("ReadUTFChunked() read past its frames")
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	WriteUTFChunked(&buf, strings.Repeat("x", maxUTF))
+	var je *JavaError
+	_, err := ReadUTFChunked(bytes.NewReader(buf.Bytes()[:2+maxUTF]))
+	if !errors.As(err, &je) || je.Exception != EOFException || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadUTFChunked(cut off) error = %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// A JavaString is encoded data indexed the way java.lang.String is, by
+// UTF-16 char rather than by byte or rune. Taking a substring shares the data
+// and its Index, so it is cheap. The zero value is the empty string.
+type JavaString struct {
+	x          *Index
+	start, end Position
+}
+
+// NewJavaString returns a JavaString for b, which must not be modified while
+// it is in use. Malformed sequences count as one char each.
+func NewJavaString(b []byte) JavaString {
+	x := NewIndex(b)
+	return JavaString{x: x, end: x.End()}
+}
+
+// Length returns the number of chars in s, as String.length.
+func (s JavaString) Length() int {
+	return s.end.Char - s.start.Char
+}
+
+// CharAt returns the char at index i, as String.charAt: each half of a
+// surrogate pair is a char of its own, and unpaired surrogates are returned
+// as is. Other malformed sequences give U+FFFD. Like Java, it panics if i is
+// out of range.
+func (s JavaString) CharAt(i int) uint16 {
+	if i < 0 || i >= s.Length() {
+		panic("jutf: index " + strconv.Itoa(i) + " out of bounds for length " + strconv.Itoa(s.Length()))
+	}
+
+	d := s.x.d[s.x.ByChar(s.start.Char+i).Byte:]
+	n, err := scan(d)
+	switch {
+	case err == nil || err == ErrUnpairedSurrogate:
+	case err == ErrTooShortSurrogate && n == 3:
+		// a high surrogate at the end
+	case err == ErrInvalidNUL:
+		return 0
+	default:
+		return utf8.RuneError
+	}
+
+	switch c := uint16(d[0]); {
+	case n == 1:
+		return c
+	case n == 2:
+		return c&0x1f<<6 | uint16(d[1]&0x3f)
+	default:
+		// 3 bytes, or the high half of a pair
+		return c&0x0f<<12 | uint16(d[1]&0x3f)<<6 | uint16(d[2]&0x3f)
+	}
+}
+
+// Substring returns the chars from begin up to end, as String.substring. A
+// surrogate pair may be split. Like Java, it panics if the range is invalid.
+func (s JavaString) Substring(begin, end int) JavaString {
+	if begin < 0 || end > s.Length() || begin > end {
+		panic("jutf: begin " + strconv.Itoa(begin) + ", end " + strconv.Itoa(end) + ", length " + strconv.Itoa(s.Length()))
+	}
+	if begin == end {
+		return JavaString{}
+	}
+	return JavaString{
+		x:     s.x,
+		start: s.x.ByChar(s.start.Char + begin),
+		end:   s.x.ByChar(s.start.Char + end),
+	}
+}
+
+// Bytes returns the encoded data of s, which must not be modified.
+func (s JavaString) Bytes() []byte {
+	if s.x == nil {
+		return nil
+	}
+	return s.x.d[s.start.Byte:s.end.Byte]
+}
+
+// String returns s decoded, with unpaired surrogates and malformed sequences
+// replaced by U+FFFD.
+func (s JavaString) String() string {
+	str, _ := Decode(s.Bytes(), Lossy())
+	return str
+}
@@ -0,0 +1,86 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestJavaString(t *testing.T) {
+	text := strings.Repeat("ab\x00\U0001f4a9åこ", 20)
+	chars := utf16.Encode([]rune(text))
+	s := NewJavaString(Encode(text))
+
+	if s.Length() != len(chars) {
+		t.Fatalf("Length() = %d, want %d", s.Length(), len(chars))
+	}
+	for i, c := range chars {
+		if got := s.CharAt(i); got != c {
+			t.Errorf("CharAt(%d) = %#x, want %#x", i, got, c)
+		}
+	}
+
+	for _, r := range [][2]int{{0, 0}, {0, 7}, {3, 5}, {4, 9}, {10, 60}, {0, len(chars)}} {
+		sub := s.Substring(r[0], r[1])
+		want := chars[r[0]:r[1]]
+		if sub.Length() != len(want) {
+			t.Fatalf("Substring(%d, %d).Length() = %d, want %d", r[0], r[1], sub.Length(), len(want))
+		}
+		for i, c := range want {
+			if got := sub.CharAt(i); got != c {
+				t.Errorf("Substring(%d, %d).CharAt(%d) = %#x, want %#x", r[0], r[1], i, got, c)
+			}
+		}
+
+		// substrings of substrings index the same way
+		if len(want) > 2 {
+			if got := sub.Substring(1, 2).CharAt(0); got != want[1] {
+				t.Errorf("Substring(%d, %d).Substring(1, 2) = %#x, want %#x", r[0], r[1], got, want[1])
+			}
+		}
+	}
+
+	// splitting a pair leaves unpaired surrogates
+	if got := s.Substring(4, 5).Bytes(); string(got) != "\xed\xb2\xa9" {
+		t.Errorf("Substring(4, 5).Bytes() = %x", got)
+	}
+	if got := s.Substring(0, 4).String(); got != "ab\x00�" {
+		t.Errorf("Substring(0, 4).String() = %q", got)
+	}
+}
+
+func TestJavaStringMalformed(t *testing.T) {
+	s := NewJavaString([]byte{'a', 0, 0xff, 0xed, 0xa0, 0xbd})
+	want := []uint16{'a', 0, 0xfffd, 0xd83d}
+	if s.Length() != len(want) {
+		t.Fatalf("Length() = %d, want %d", s.Length(), len(want))
+	}
+	for i, c := range want {
+		if got := s.CharAt(i); got != c {
+			t.Errorf("CharAt(%d) = %#x, want %#x", i, got, c)
+		}
+	}
+}
+
+func TestJavaStringBounds(t *testing.T) {
+	s := NewJavaString([]byte("abc"))
+	for _, fn := range []func(){
+		func() { s.CharAt(3) },
+		func() { s.CharAt(-1) },
+		func() { s.Substring(2, 1) },
+		func() { s.Substring(0, 4) },
+		func() { JavaString{}.CharAt(0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -2,20 +2,18 @@
 // MIT license (see LICENSE).
 
 // Package jutf implements the modified UTF-8 encoding used by Java.
+//
+// Encode and Decode allocate their result and nothing else. The Append and
+// Into variants, as well as Codec, do not allocate at all given a large
+// enough buffer. The encode and decode paths do not use reflect or
+// bytes.Buffer, so they work under TinyGo and WebAssembly. Helpers that
+// need more, for struct records, jar files and databases, are in the
+// record, jar and jutfsql subpackages.
 package jutf
 
 import (
-	"bytes"
-	"errors"
 	"unicode/utf8"
-)
-
-// not yet exported.
-var (
-	errInvalidNUL        = errors.New("short NUL codepoint not allowed")
-	errTooShort          = errors.New("unexpected end of data")
-	errTooShortSurrogate = errors.New("unexpected end of data (missing surrogate)")
-	errInvalidEncoding   = errors.New("invalid encoding")
+	"unsafe"
 )
 
 //
@@ -41,132 +39,387 @@ var (
 // 3. (0x437 & 0x3ff) + 0xdc00 = 0xdc37
 //
 
-// Encode returns a string in modified UTF-8 format.
-func Encode(s string) []byte {
-	buf := bytes.Buffer{}
-
-	// Output will be at least as long as s, potentially longer
-	buf.Grow(len(s))
-
-	// up to 6 byte long encoding
-	tmp := make([]byte, 6)
-
-	for _, r := range s {
-		if r == 0 {
-			tmp[0] = 0xc0
-			tmp[1] = 0x80
-
-			buf.Write(tmp[0:2])
-		} else if r >= 1 && r <= 0x7f {
-			buf.WriteByte(byte(r))
-		} else if r >= 0x80 && r <= 0x7ff {
-			tmp[0] = byte(0xc0 | (r >> 6))
-			tmp[1] = byte(0x80 | (r & 0x3f))
-
-			buf.Write(tmp[0:2])
-		} else if r >= 0x800 && r <= 0xffff {
-			tmp[0] = byte(0xe0 | ((r >> 12) & 0xf))
-			tmp[1] = byte(0x80 | ((r >> 6) & 0x3f))
-			tmp[2] = byte(0x80 | (r & 0x3f))
-
-			buf.Write(tmp[0:3])
-		} else if r >= 0x10000 && r <= 0x10ffff {
-			// codepoint 1
-			r1 := ((r - 0x10000) >> 10) + 0xd800
-			tmp[0] = byte(0xe0 | ((r1 >> 12) & 0xf))
-			tmp[1] = byte(0x80 | ((r1 >> 6) & 0x3f))
-			tmp[2] = byte(0x80 | (r1 & 0x3f))
-
-			// codepoint 2
-			r2 := ((r - 0x10000) & 0x3ff) + 0xdc00
-			tmp[3] = byte(0xe0 | ((r2 >> 12) & 0xf))
-			tmp[4] = byte(0x80 | ((r2 >> 6) & 0x3f))
-			tmp[5] = byte(0x80 | (r2 & 0x3f))
-
-			buf.Write(tmp[0:6])
+// Encode returns a string in modified UTF-8 format. Of the options, only
+// RawNUL and KeepSurrogates apply.
+func Encode(s string, opts ...Option) []byte {
+	o := newOptions(opts)
+	return encode(make([]byte, 0, encodedLen(s, &o)), s, &o)
+}
+
+// EncodedLen returns the length in bytes of the modified UTF-8 encoding of
+// s, that is len(Encode(s)).
+func EncodedLen(s string) int {
+	return encodedLen(s, &options{})
+}
+
+// encodedLen is EncodedLen for the encoding with the options in o.
+func encodedLen(s string, o *options) int {
+	n := len(s)
+	b := stringBytes(s)
+
+	for i := 0; i < len(s); {
+		if i += asciiSpan(b[i:]); i == len(s) {
+			break
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == 0:
+			if !o.rawNUL {
+				n++
+			}
+		case size == 4:
+			n += 2
+		case size == 1 && o.keepSurr && surrogateLen(s[i:]) > 0:
+			size = 3
+		case size == 1:
+			// invalid, U+FFFD
+			n += 2
+		}
+		i += size
+	}
+
+	return n
+}
+
+// EncodeBytes is like Encode, but for input that is a byte slice, which is
+// not copied to a string first.
+func EncodeBytes(b []byte, opts ...Option) []byte {
+	return Encode(unsafe.String(unsafe.SliceData(b), len(b)), opts...)
+}
+
+// encode appends the modified UTF-8 encoding of s to dst. Everything but
+// NULs, supplementary characters and invalid UTF-8 is the same in both
+// encodings, so runs of those are copied as is.
+func encode(dst []byte, s string, o *options) []byte {
+	last := 0 // start of the run not yet copied to dst
+	b := stringBytes(s)
+
+	for i := 0; i < len(s); {
+		if i += asciiSpan(b[i:]); i == len(s) {
+			break
+		}
+
+		if c := s[i]; c >= 0x80 && c < 0xf0 {
+			// 2 and 3 byte sequences, unless invalid
+			if _, n := utf8.DecodeRuneInString(s[i:]); n > 1 {
+				i += n
+				continue
+			}
+			if o.keepSurr && surrogateLen(s[i:]) > 0 {
+				i += 3
+				continue
+			}
+		}
+
+		dst = append(dst, s[last:i]...)
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == 0 && o.rawNUL {
+			dst = append(dst, 0)
 		} else {
-			// panic("out of range rune >0x10ffff")
-			buf.Write([]byte("\ufffd")) // replacement character
+			dst = appendRune(dst, r)
 		}
+
+		i += n
+		last = i
 	}
 
-	return buf.Bytes()
+	return append(dst, s[last:]...)
+}
+
+// surrogateLen returns 3 if s starts with the 3-byte form of a surrogate, as
+// allowed by WTF-8, and 0 otherwise.
+func surrogateLen(s string) int {
+	if len(s) >= 3 && s[0] == 0xed && s[1]&0xe0 == 0xa0 && s[2]&0xc0 == 0x80 {
+		return 3
+	}
+	return 0
 }
 
-// Decode decodes the input array to a UTF-8 string.
-func Decode(d []byte) (string, error) {
+// stringBytes returns the bytes of s without copying. They must not be
+// modified.
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// EncodeToString is like Encode, but returns the result as a string. If s
+// needs no encoding it is returned as is.
+func EncodeToString(s string, opts ...Option) string {
+	o := newOptions(opts)
+	if !NeedsEncoding(s) {
+		return s
+	}
+
+	// buf is not used after this, so there's no need to copy it
+	buf := encode(make([]byte, 0, encodedLen(s, &o)), s, &o)
+	return unsafe.String(&buf[0], len(buf))
+}
+
+// NeedsEncoding reports whether Encode(s) differs from []byte(s), that is
+// whether s contains NUL, supplementary characters or invalid UTF-8. If not,
+// s can be used as modified UTF-8 as is.
+func NeedsEncoding(s string) bool {
+	b := stringBytes(s)
+
+	for i := 0; i < len(s); {
+		if i += asciiSpan(b[i:]); i == len(s) {
+			break
+		}
+
+		if c := s[i]; c == 0 || c >= 0xf0 {
+			return true
+		}
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			return true
+		}
+		i += n
+	}
+	return false
+}
+
+// Decode decodes the input array to a UTF-8 string. Errors are of type
+// *DecodeError.
+func Decode(d []byte, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	return decodeString(d, &o)
+}
+
+// DecodeString is like Decode, but for input that is a string. If s needs no
+// transformation it is returned as is.
+func DecodeString(s string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	o.zeroCopy = true
+	return decodeString(stringBytes(s), &o)
+}
+
+func decodeString(d []byte, o *options) (string, error) {
+	if err := o.checkLimits(d); err != nil {
+		return "", err
+	}
+
 	// if the input already is a normal UTF-8 string, simply return it
-	if utf8.ValidString(string(d)) {
-		return string(d), nil
+	start := o.bomLen(d)
+	i := same(d, start, len(d), o)
+	if i == len(d) {
+		if o.zeroCopy && len(d) > start {
+			return unsafe.String(&d[start], len(d)-start), nil
+		}
+		return string(d[start:]), nil
+	}
+
+	// the output is never longer than the input, except when Lossy
+	// replaces single bytes with U+FFFD.
+	buf := append(make([]byte, 0, len(d)), d[start:i]...)
+	buf, err := decode(buf, d, i, len(d), o)
+	if err != nil || len(buf) == 0 {
+		// Skip may have left nothing
+		return "", err
 	}
 
-	buf := bytes.Buffer{}
-	buf.Grow(len(d)) // the final length of the output should be similar to the input.
+	// buf is not used after this, so there's no need to copy it
+	return unsafe.String(&buf[0], len(buf)), nil
+}
+
+// MustDecode is like Decode, but panics if d is malformed. It is meant for
+// test fixtures and trusted constants.
+func MustDecode(d []byte, opts ...Option) string {
+	s, err := Decode(d, opts...)
+	if err != nil {
+		panic("jutf: " + err.Error())
+	}
+	return s
+}
 
+// DecodeToBytes is like Decode, but returns the UTF-8 as a byte slice. With
+// ZeroCopy, d itself is returned if it needs no transformation.
+func DecodeToBytes(d []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+
+	if err := o.checkLimits(d); err != nil {
+		return nil, err
+	}
+
+	start := o.bomLen(d)
+	i := same(d, start, len(d), &o)
+	if i == len(d) && o.zeroCopy {
+		return d[start:], nil
+	}
+
+	buf := append(make([]byte, 0, len(d)), d[start:i]...)
+	buf, err := decode(buf, d, i, len(d), &o)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// DecodedLen returns the length in bytes of the UTF-8 that d decodes to.
+// Raw NULs and 4-byte sequences count as themselves, other malformed input
+// as U+FFFD.
+func DecodedLen(d []byte) int {
+	n, _ := decodedLen(d, -1)
+	return n
+}
+
+// overLimit returns the offset of the sequence in d whose decoding takes the
+// output past limit bytes, or -1 if there is none.
+func overLimit(d []byte, limit int) int {
+	_, at := decodedLen(d, limit)
+	return at
+}
+
+// decodedLen returns the decoded length of d. If limit is not negative it
+// stops when that is exceeded, and returns the offset where that happened.
+func decodedLen(d []byte, limit int) (n, at int) {
 	for i := 0; i < len(d); {
-		if d[i] == 0 {
-			// a short NUL, valid and reasonable except this is Java UTF-8.
-			return "", errInvalidNUL
-		} else if d[i] < 0x80 {
-			// ASCII range, can simply copy it
-			buf.WriteByte(d[i])
-			i++
-		} else if d[i]&0xe0 == 0xc0 {
-			// 2 bytes
-			if i+1 >= len(d) {
-				return "", errTooShort
-			}
+		span := asciiSpan(d[i:])
+		if n += span; limit >= 0 && n > limit {
+			return n, i + span - (n - limit)
+		}
+		if i += span; i == len(d) {
+			break
+		}
 
-			if d[i] == 0xc0 && d[i+1] == 0x80 {
-				// "overlong" null
-				buf.WriteByte(0)
-			} else {
-				// copy
-				buf.WriteByte(d[i])
-				buf.WriteByte(d[i+1])
-			}
+		size, err := scan(d[i:])
+		switch {
+		case err == ErrInvalidNUL || err == ErrFourByte:
+			n += size
+		case err != nil:
+			n += 3
+		case size == 6:
+			n += 4
+		case d[i] == 0xc0:
+			n++
+		default:
+			n += size
+		}
+		if limit >= 0 && n > limit {
+			return n, i
+		}
+		i += size
+	}
 
-			i += 2
-		} else if d[i]&0xf0 == 0xe0 {
-			// 3 bytes
-			if i+2 >= len(d) {
-				return "", errTooShort
-			}
+	return n, -1
+}
+
+// same returns the end of the longest run of d[start:end] that decodes to
+// itself. Unless Strict is used, raw NULs and 4-byte sequences are part of
+// it only if all of d[start:end] is standard UTF-8.
+func same(d []byte, start, end int, o *options) int {
+	std := false // seen standard UTF-8 forms
+
+	for i := start; i < end; {
+		if i += asciiSpan(d[i:end]); i == end {
+			break
+		}
+
+		n, err := scan(d[i:])
 
-			// surrogate pair, first codepoint
-			if d[i] == 0xed && d[i+1] >= 0xa0 && d[i+1] <= 0xaf {
-				// must be followed by a 3 byte codepoint
-				if i+5 >= len(d) {
-					return "", errTooShortSurrogate
-				}
-
-				// make sure the next codepoint is part of the surrogate pair
-				if d[i+3] != 0xed || !(d[i+4] >= 0xb0 && d[i+4] <= 0xbf) {
-					return "", errInvalidEncoding
-				}
-
-				// decode the whole surrogate pair
-				c1 := int32(d[i]&0xf) << 12
-				c1 |= int32(d[i+1]&0x3f) << 6
-				c1 |= int32(d[i+2] & 0x3f)
-				c2 := int32(d[i+3]&0xf) << 12
-				c2 |= int32(d[i+4]&0x3f) << 6
-				c2 |= int32(d[i+5] & 0x3f)
-				cp := 0x10000 + ((c1 - 0xd800) << 10) | (c2 - 0xdc00)
-
-				buf.WriteRune(rune(cp))
-				i += 3 // eat the second half
-			} else {
-				// others can be copied
-				buf.Write(d[i : i+3])
+		switch {
+		case err == nil && (n == 6 || d[i] == 0xc0):
+			// modified forms, so d is not standard UTF-8
+			if std {
+				return start
 			}
+			return i
+		case err == ErrInvalidNUL && o.rawNUL:
+			// decodes to itself
+		case (err == ErrInvalidNUL || err == ErrFourByte) && !o.strict:
+			std = true
+		case err != nil:
+			if std {
+				return start
+			}
+			return i
+		}
 
-			i += 3
-		} else {
-			// would be >3 bytes (invalid)
-			return "", errInvalidEncoding
+		i += n
+	}
+
+	return end
+}
+
+// decode appends the decoding of d[i:end] to dst. The caller handles MaxLen
+// and input that is already valid UTF-8. Sequences are checked against all
+// of d, so end must be at the start of one.
+func decode(dst, d []byte, i, end int, o *options) ([]byte, error) {
+	for i < end {
+		// ASCII range, can simply copy it
+		n := asciiSpan(d[i:end])
+		dst = append(dst, d[i:i+n]...)
+		if i += n; i == end {
+			break
+		}
+
+		n, err := scan(d[i:])
+		if dst, err = decodeSeq(dst, d[i:i+n], err, o); err != nil {
+			return dst, newDecodeError(d, i, err)
 		}
+
+		i += n
+	}
+
+	return dst, nil
+}
+
+// decodeSeq appends the decoding of seq, a sequence found by scan, to dst.
+// The error from scan is returned unless the options allow for it.
+func decodeSeq(dst, seq []byte, err error, o *options) ([]byte, error) {
+	switch {
+	case err == nil && seq[0] == 0xc0:
+		// "overlong" null
+		dst = append(dst, 0)
+	case err == nil && len(seq) == 6:
+		var tmp [utf8.UTFMax]byte
+		n := utf8.EncodeRune(tmp[:], decodePair(seq))
+		dst = append(dst, tmp[:n]...)
+	case err == nil:
+		// others can be copied
+		dst = append(dst, seq...)
+	case err == ErrInvalidNUL && o.rawNUL:
+		dst = append(dst, 0)
+	case (err == ErrInvalidNUL || err == ErrFourByte) && o.std:
+		dst = append(dst, seq...)
+	case (err == ErrUnpairedSurrogate || err == ErrTooShortSurrogate) && o.surrogates, o.onError == Replace:
+		dst = append(dst, "\ufffd"...)
+	case o.onError == Skip:
+	case o.onError == Passthrough:
+		dst = append(dst, seq...)
+	default:
+		return dst, err
+	}
+	return dst, nil
+}
+
+// appendRune appends the modified UTF-8 encoding of r to b. Runes that are
+// out of range are replaced with U+FFFD.
+func appendRune(b []byte, r rune) []byte {
+	switch {
+	case r == 0:
+		return append(b, 0xc0, 0x80)
+	case r <= 0x7f:
+		return append(b, byte(r))
+	case r <= 0x7ff:
+		return append(b, byte(0xc0|(r>>6)), byte(0x80|(r&0x3f)))
+	case r <= 0xffff:
+		return append(b, byte(0xe0|((r>>12)&0xf)), byte(0x80|((r>>6)&0x3f)), byte(0x80|(r&0x3f)))
+	case r <= 0x10ffff:
+		hi, lo := EncodeSurrogatePair(r)
+		return appendRune(appendRune(b, rune(hi)), rune(lo))
 	}
+	return append(b, "\ufffd"...)
+}
 
-	return buf.String(), nil
+// decodePair decodes the 6 byte surrogate pair at the start of d.
+func decodePair(d []byte) rune {
+	c1 := int32(d[0]&0xf) << 12
+	c1 |= int32(d[1]&0x3f) << 6
+	c1 |= int32(d[2] & 0x3f)
+	c2 := int32(d[3]&0xf) << 12
+	c2 |= int32(d[4]&0x3f) << 6
+	c2 |= int32(d[5] & 0x3f)
+	return 0x10000 + ((c1 - 0xd800) << 10) | (c2 - 0xdc00)
 }
@@ -4,6 +4,7 @@
 package jutf
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -32,6 +33,29 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+func TestNeedsEncoding(t *testing.T) {
+	tests := []struct {
+		str  string
+		want bool
+	}{
+		{"", false},
+		{"java/lang/Object", false},
+		{"åäö 日本語 \ufffd", false},
+		{"a\x00b", true},
+		{"\U0001f4a9", true},
+		{"bad \xff", true},
+		{"surrogate \xed\xa0\xbd", true},
+	}
+	for _, tt := range tests {
+		if got := NeedsEncoding(tt.str); got != tt.want {
+			t.Errorf("NeedsEncoding(%q) = %v, want %v", tt.str, got, tt.want)
+		}
+		if got := string(Encode(tt.str)) != tt.str; got != tt.want {
+			t.Errorf("Encode(%q) changed = %v, want %v", tt.str, got, tt.want)
+		}
+	}
+}
+
 func TestDecode(t *testing.T) {
 	tests := []struct {
 		name string
@@ -51,6 +75,59 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestEncodedLen(t *testing.T) {
+	for _, s := range []string{"", "ASCII", "a\x00b", "åäö 日本語 \U0001f4a9", "bad \xff\xed\xa0\xbd"} {
+		if got, want := EncodedLen(s), len(Encode(s)); got != want {
+			t.Errorf("EncodedLen(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestDecodedLen(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want int
+	}{
+		{[]byte{}, 0},
+		{Encode("a\x00åäö 日本語 \U0001f4a9"), len("a\x00åäö 日本語 \U0001f4a9")},
+		{[]byte("a\x00\U0001f4a9"), 6},
+		{[]byte{'a', 0xff, 0xed, 0xa0, 0xbd}, 7},
+	}
+	for _, tt := range tests {
+		if got := DecodedLen(tt.data); got != tt.want {
+			t.Errorf("DecodedLen(%x) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeToString(t *testing.T) {
+	for _, s := range []string{"", "ASCII", "a\x00b", "åäö 日本語 \U0001f4a9", "bad \xff"} {
+		if got, want := EncodeToString(s), string(Encode(s)); got != want {
+			t.Errorf("EncodeToString(%q) = %q, want %q", s, got, want)
+		}
+	}
+	if got := EncodeToString("a\x00", RawNUL()); got != "a\x00" {
+		t.Errorf("EncodeToString() = %q, want %q", got, "a\x00")
+	}
+}
+
+func TestDecodeToBytes(t *testing.T) {
+	for _, s := range []string{"", "ASCII", "a\x00b", "åäö 日本語 \U0001f4a9"} {
+		got, err := DecodeToBytes(Encode(s))
+		if err != nil || string(got) != s {
+			t.Errorf("DecodeToBytes() = %q, %v; want %q", got, err, s)
+		}
+	}
+
+	d := []byte("ASCII")
+	if got, _ := DecodeToBytes(d, ZeroCopy()); &got[0] != &d[0] {
+		t.Errorf("DecodeToBytes() copied its input")
+	}
+	if _, err := DecodeToBytes([]byte{0xff}); err == nil {
+		t.Errorf("DecodeToBytes() returned no error")
+	}
+}
+
 func TestEncodeSame(t *testing.T) {
 	// all of these should be the same in utf-8 and java modified utf-8.
 	for i := 1; i <= 0xffff; i++ {
@@ -76,6 +153,17 @@ func TestEncodeDecodeAll(t *testing.T) {
 	}
 }
 
+func TestAllocs(t *testing.T) {
+	s := "Hello\x00Wörld!!! \U0001f4a9"
+	d := Encode(s)
+	if n := testing.AllocsPerRun(100, func() { Encode(s) }); n != 1 {
+		t.Errorf("Encode() allocates %v times, want 1", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { Decode(d) }); n != 1 {
+		t.Errorf("Decode() allocates %v times, want 1", n)
+	}
+}
+
 func BenchmarkEncode(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		Encode("Hello\x00Wörld!!! \U0001f4a9")
@@ -88,3 +176,39 @@ func BenchmarkDecode(b *testing.B) {
 		_, _ = Decode(tmp)
 	}
 }
+
+func TestMustDecode(t *testing.T) {
+	if s := MustDecode(Encode("a\x00b")); s != "a\x00b" {
+		t.Errorf("MustDecode() = %q", s)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("MustDecode() did not panic")
+		}
+	}()
+	MustDecode([]byte{0xff})
+}
+
+func TestPairedVariants(t *testing.T) {
+	for _, s := range []string{"", "abc", "a\x00b\U0001f4a9"} {
+		if got := EncodeBytes([]byte(s)); string(got) != string(Encode(s)) {
+			t.Errorf("EncodeBytes(%q) = %x, want %x", s, got, Encode(s))
+		}
+		if got, err := DecodeString(string(Encode(s))); got != s || err != nil {
+			t.Errorf("DecodeString(%q) = %q, %v", Encode(s), got, err)
+		}
+	}
+
+	if _, err := DecodeString("\xff"); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("DecodeString(ff) error = %v", err)
+	}
+
+	s := "java/lang/Object"
+	if n := testing.AllocsPerRun(10, func() { DecodeString(s) }); n != 0 {
+		t.Errorf("DecodeString allocates %v times for plain input", n)
+	}
+	if n := testing.AllocsPerRun(10, func() { EncodeBytes([]byte("a\x00")) }); n != 1 {
+		t.Errorf("EncodeBytes allocates %v times, want 1", n)
+	}
+}
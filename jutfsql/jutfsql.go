@@ -0,0 +1,42 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package jutfsql stores strings in databases as modified UTF-8.
+package jutfsql
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/anders/jutf"
+)
+
+// String is a string stored in a database as modified UTF-8, such as a BLOB
+// column written by DataOutput. It implements sql.Scanner and driver.Valuer.
+type String string
+
+// Scan implements sql.Scanner, decoding a []byte or string column. NULL is
+// an error, as it is for a plain string.
+func (s *String) Scan(src any) error {
+	var str string
+	var err error
+	switch src := src.(type) {
+	case []byte:
+		str, err = jutf.Decode(src)
+	case string:
+		str, err = jutf.DecodeString(src)
+	default:
+		return fmt.Errorf("jutfsql: cannot scan %T into String", src)
+	}
+
+	if err != nil {
+		return err
+	}
+	*s = String(str)
+	return nil
+}
+
+// Value implements driver.Valuer, returning the encoding of s.
+func (s String) Value() (driver.Value, error) {
+	return jutf.Encode(string(s)), nil
+}
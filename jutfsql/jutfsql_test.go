@@ -0,0 +1,50 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutfsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/anders/jutf"
+)
+
+func TestStringScan(t *testing.T) {
+	var _ sql.Scanner = (*String)(nil)
+	var _ driver.Valuer = String("")
+
+	tests := []struct {
+		src  any
+		want String
+		ok   bool
+	}{
+		{jutf.Encode("a\x00\U0001f4a9"), "a\x00\U0001f4a9", true},
+		{string(jutf.Encode("a\x00")), "a\x00", true},
+		{[]byte{}, "", true},
+		{[]byte{0xff}, "", false},
+		{nil, "", false},
+		{int64(1), "", false},
+	}
+	for _, tt := range tests {
+		var s String
+		err := s.Scan(tt.src)
+		if s != tt.want || (err == nil) != tt.ok {
+			t.Errorf("Scan(%#v) = %q, %v", tt.src, s, err)
+		}
+	}
+
+	var s String
+	if err := s.Scan([]byte{0xff}); !errors.Is(err, jutf.ErrInvalidEncoding) {
+		t.Errorf("Scan(ff) error = %v", err)
+	}
+}
+
+func TestStringValue(t *testing.T) {
+	v, err := String("a\x00\U0001f4a9").Value()
+	if b, ok := v.([]byte); err != nil || !ok || string(b) != string(jutf.Encode("a\x00\U0001f4a9")) {
+		t.Errorf("Value() = %#v, %v", v, err)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package jutftest generates modified UTF-8 for tests, both valid and
+// pathological, for property tests of code that uses jutf. The Encoded and
+// Pathological types implement quick.Generator. Vectors are fixed cases of
+// how the JVM decodes, for other implementations to check against.
+package jutftest
+
+import (
+	"math/rand"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/anders/jutf"
+)
+
+// String returns a random string of n runes, mixing ASCII, NULs and runes
+// that encode to 2, 3 and 6 bytes.
+func String(r *rand.Rand, n int) string {
+	b := make([]byte, 0, n)
+	for range n {
+		b = utf8.AppendRune(b, Rune(r))
+	}
+	return string(b)
+}
+
+// Rune returns a random rune, other than a surrogate, favouring the ones
+// that modified UTF-8 treats specially.
+func Rune(r *rand.Rand) rune {
+	switch r.Intn(6) {
+	case 0:
+		return 0
+	case 1, 2:
+		return rune(1 + r.Intn(0x7f))
+	case 3:
+		return rune(0x80 + r.Intn(0x800-0x80))
+	case 4:
+		c := rune(0x800 + r.Intn(0x10000-0x800-0x800))
+		if c >= 0xd800 {
+			c += 0x800
+		}
+		return c
+	}
+	return rune(0x10000 + r.Intn(0x100000))
+}
+
+// Valid returns the canonical encoding of a random string of n runes.
+func Valid(r *rand.Rand, n int) []byte {
+	return jutf.Encode(String(r, n))
+}
+
+// NULs returns canonical data of n runes, most of which are NUL.
+func NULs(r *rand.Rand, n int) []byte {
+	b := make([]byte, 0, 2*n)
+	for range n {
+		if r.Intn(8) == 0 {
+			b = jutf.AppendEncode(b, string(Rune(r)))
+		} else {
+			b = append(b, 0xc0, 0x80)
+		}
+	}
+	return b
+}
+
+// Surrogates returns n encoded surrogates, mostly in pairs but with some
+// unpaired or reversed halves, which are not valid.
+func Surrogates(r *rand.Rand, n int) []byte {
+	b := make([]byte, 0, 3*n)
+	for i := 0; i < n; i++ {
+		hi, lo := jutf.EncodeSurrogatePair(rune(0x10000 + r.Intn(0x100000)))
+		switch r.Intn(16) {
+		case 0:
+			b = appendChar(b, hi)
+		case 1:
+			b = appendChar(b, lo)
+		case 2:
+			b = appendChar(appendChar(b, lo), hi)
+			i++
+		default:
+			b = appendChar(appendChar(b, hi), lo)
+			i++
+		}
+	}
+	return b
+}
+
+// Truncated returns valid data of n runes cut off in the middle of its last
+// sequence that is longer than a byte, or as is if it has none.
+func Truncated(r *rand.Rand, n int) []byte {
+	b := Valid(r, n)
+	end := len(b)
+	for end > 0 && b[end-1] < utf8.RuneSelf {
+		end--
+	}
+	if end == 0 {
+		return b
+	}
+
+	// find the start of the sequence ending at end
+	start := end - 1
+	for start > 0 && b[start]&0xc0 == 0x80 {
+		start--
+	}
+	return b[:start+1+r.Intn(end-start-1)]
+}
+
+// Malformed returns valid data of n runes with some bytes replaced, inserted
+// or removed at random.
+func Malformed(r *rand.Rand, n int) []byte {
+	b := Valid(r, n)
+	for range 1 + len(b)/16 {
+		i := r.Intn(len(b) + 1)
+		switch r.Intn(3) {
+		case 0:
+			if i < len(b) {
+				b[i] = byte(r.Intn(256))
+			}
+		case 1:
+			b = append(b[:i], append([]byte{byte(0x80 + r.Intn(0x80))}, b[i:]...)...)
+		case 2:
+			if i < len(b) {
+				b = append(b[:i], b[i+1:]...)
+			}
+		}
+	}
+	return b
+}
+
+// Standard returns the standard UTF-8 encoding of a random string of n
+// runes, which has raw NULs and 4-byte sequences.
+func Standard(r *rand.Rand, n int) []byte {
+	return []byte(String(r, n))
+}
+
+// appendChar appends the 3-byte encoding of the UTF-16 char c.
+func appendChar(b []byte, c uint16) []byte {
+	return append(b, 0xe0|byte(c>>12), 0x80|byte(c>>6)&0x3f, 0x80|byte(c)&0x3f)
+}
+
+// Encoded is canonical modified UTF-8. Its Generate method makes
+// testing/quick produce valid data of up to size runes.
+type Encoded []byte
+
+// Generate implements quick.Generator.
+func (Encoded) Generate(r *rand.Rand, size int) reflect.Value {
+	var b []byte
+	if r.Intn(4) == 0 {
+		b = NULs(r, r.Intn(size+1))
+	} else {
+		b = Valid(r, r.Intn(size+1))
+	}
+	return reflect.ValueOf(Encoded(b))
+}
+
+// Pathological is data that is likely malformed. Its Generate method makes
+// testing/quick produce the output of Surrogates, Truncated, Malformed or
+// Standard, of up to size runes.
+type Pathological []byte
+
+// Generate implements quick.Generator.
+func (Pathological) Generate(r *rand.Rand, size int) reflect.Value {
+	gens := []func(*rand.Rand, int) []byte{Surrogates, Truncated, Malformed, Standard}
+	b := gens[r.Intn(len(gens))](r, r.Intn(size+1))
+	return reflect.ValueOf(Pathological(b))
+}
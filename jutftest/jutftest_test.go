@@ -0,0 +1,79 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutftest
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/anders/jutf"
+)
+
+func TestGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 200 {
+		n := 1 + r.Intn(50)
+		if s := String(r, n); utf8.RuneCountInString(s) != n || !utf8.ValidString(s) {
+			t.Fatalf("String(%d) = %q", n, s)
+		}
+		if b := Valid(r, n); !jutf.Valid(b) {
+			t.Fatalf("Valid(%d) = %x, not valid", n, b)
+		}
+		if b := NULs(r, n); !jutf.Valid(b) {
+			t.Fatalf("NULs(%d) = %x, not valid", n, b)
+		}
+		if b := Truncated(r, n); jutf.Valid(b) && !isASCII(b) {
+			t.Fatalf("Truncated(%d) = %x, valid", n, b)
+		}
+		if b := Standard(r, n); !utf8.Valid(b) {
+			t.Fatalf("Standard(%d) = %x, not UTF-8", n, b)
+		}
+	}
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSurrogates(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	invalid := 0
+	for range 100 {
+		b := Surrogates(r, 64)
+		if len(b)%3 != 0 {
+			t.Fatalf("Surrogates() = %x, not whole chars", b)
+		}
+		if !jutf.Valid(b) {
+			invalid++
+		}
+	}
+	if invalid == 0 {
+		t.Errorf("Surrogates() never made invalid data")
+	}
+}
+
+func TestQuick(t *testing.T) {
+	roundTrip := func(b Encoded) bool {
+		s, err := jutf.Decode(b)
+		return err == nil && string(jutf.Encode(s)) == string(b)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+
+	lossy := func(b Pathological) bool {
+		s, err := jutf.Decode(b, jutf.Lossy())
+		return err == nil && utf8.ValidString(s)
+	}
+	if err := quick.Check(lossy, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutftest
+
+import "github.com/anders/jutf"
+
+// A Vector is a conformance test case for decoding, with the result of
+// passing Input to DataInputStream.readUTF (after its length prefix).
+type Vector struct {
+	Name  string
+	Input []byte
+
+	// Chars are the chars of the String that readUTF returns. Unpaired
+	// surrogates are kept, as Java keeps them.
+	Chars []uint16
+
+	// Exception is what readUTF throws instead, or 0, with Msg being the
+	// exception message.
+	Exception jutf.Exception
+	Msg       string
+
+	// Canonical reports whether Input is what writeUTF produces for Chars,
+	// and so whether jutf.Valid accepts it.
+	Canonical bool
+}
+
+// Vectors returns the conformance vectors, as a new slice that the caller
+// may modify. They cover the forms the JVM writes, the malformed forms it
+// accepts anyway and those it throws on.
+func Vectors() []Vector {
+	const format = jutf.UTFDataFormatException
+	const partial = "malformed input: partial character at end"
+
+	return []Vector{
+		{Name: "empty", Input: []byte{}, Chars: []uint16{}, Canonical: true},
+		{Name: "ASCII", Input: []byte("abc"), Chars: []uint16{'a', 'b', 'c'}, Canonical: true},
+		{Name: "NUL", Input: []byte{0xc0, 0x80}, Chars: []uint16{0}, Canonical: true},
+		{Name: "2-byte", Input: []byte{0xc3, 0xa5}, Chars: []uint16{0xe5}, Canonical: true},
+		{Name: "3-byte", Input: []byte{0xe6, 0x97, 0xa5}, Chars: []uint16{0x65e5}, Canonical: true},
+		{Name: "U+FFFF", Input: []byte{0xef, 0xbf, 0xbf}, Chars: []uint16{0xffff}, Canonical: true},
+		{Name: "surrogate pair", Input: []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, Chars: []uint16{0xd83d, 0xdca9}, Canonical: true},
+
+		// accepted by readUTF, but never written by writeUTF
+		{Name: "raw NUL", Input: []byte{'a', 0, 'b'}, Chars: []uint16{'a', 0, 'b'}},
+		{Name: "lone high surrogate", Input: []byte{0xed, 0xa0, 0xbd}, Chars: []uint16{0xd83d}},
+		{Name: "lone low surrogate", Input: []byte{0xed, 0xb2, 0xa9}, Chars: []uint16{0xdca9}},
+		{Name: "reversed pair", Input: []byte{0xed, 0xb2, 0xa9, 0xed, 0xa0, 0xbd}, Chars: []uint16{0xdca9, 0xd83d}},
+		{Name: "overlong 2-byte", Input: []byte{0xc1, 0x81}, Chars: []uint16{'A'}},
+		{Name: "overlong 3-byte NUL", Input: []byte{0xe0, 0x80, 0x80}, Chars: []uint16{0}},
+
+		// thrown on
+		{Name: "4-byte", Input: []byte{0xf0, 0x9f, 0x92, 0xa9}, Exception: format, Msg: "malformed input around byte 0"},
+		{Name: "5-byte lead", Input: []byte{'a', 0xf8, 0x80}, Exception: format, Msg: "malformed input around byte 1"},
+		{Name: "invalid byte", Input: []byte{0xff}, Exception: format, Msg: "malformed input around byte 0"},
+		{Name: "stray continuation", Input: []byte{'a', 'b', 0x80}, Exception: format, Msg: "malformed input around byte 2"},
+		{Name: "bad 2-byte continuation", Input: []byte{'a', 0xc3, 'b'}, Exception: format, Msg: "malformed input around byte 3"},
+		{Name: "bad 3-byte continuation", Input: []byte{0xe6, 0x97, 'b'}, Exception: format, Msg: "malformed input around byte 2"},
+		{Name: "bad 3-byte second byte", Input: []byte{0xe6, 'b', 0xa5}, Exception: format, Msg: "malformed input around byte 2"},
+		{Name: "partial 2-byte", Input: []byte{'a', 0xc3}, Exception: format, Msg: partial},
+		{Name: "partial 3-byte", Input: []byte{0xe6, 0x97}, Exception: format, Msg: partial},
+		{Name: "partial pair", Input: []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2}, Exception: format, Msg: partial},
+	}
+}
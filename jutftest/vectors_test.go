@@ -0,0 +1,42 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutftest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/anders/jutf"
+)
+
+func TestVectors(t *testing.T) {
+	for _, v := range Vectors() {
+		t.Run(v.Name, func(t *testing.T) {
+			got, err := jutf.DecodeJava(v.Input)
+
+			var je *jutf.JavaError
+			if v.Exception != 0 {
+				if !errors.As(err, &je) || je.Exception != v.Exception || je.Msg != v.Msg {
+					t.Errorf("DecodeJava() error = %v, want %v: %s", err, v.Exception, v.Msg)
+				}
+			} else if want := string(utf16.Decode(v.Chars)); err != nil || got != want {
+				t.Errorf("DecodeJava() = %q, %v; want %q", got, err, want)
+			}
+			if chars, err := jutf.DecodeToUTF16(v.Input); v.Exception == 0 && (err != nil || !slices.Equal(chars, v.Chars)) {
+				t.Errorf("DecodeToUTF16() = %x, %v; want %x", chars, err, v.Chars)
+			}
+
+			if jutf.Valid(v.Input) != v.Canonical {
+				t.Errorf("Valid() = %v, want %v", !v.Canonical, v.Canonical)
+			}
+			if v.Canonical {
+				if enc := jutf.FromUTF16(v.Chars); string(enc) != string(v.Input) {
+					t.Errorf("FromUTF16() = %x, want %x", enc, v.Input)
+				}
+			}
+		})
+	}
+}
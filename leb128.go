@@ -0,0 +1,46 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"io"
+)
+
+var errULEB128 = errors.New("ULEB128 value overflows 32 bits")
+
+// ReadULEB128 reads an unsigned LEB128 value of up to 32 bits, as used for
+// lengths in dex files: 7 bits per byte, least significant first, with the
+// high bit set on all but the last byte. It returns io.EOF if r is at its
+// end, and io.ErrUnexpectedEOF if it ends within the value.
+func ReadULEB128(r io.ByteReader) (uint32, error) {
+	var v uint32
+	for shift := 0; ; shift += 7 {
+		c, err := r.ReadByte()
+		if err == io.EOF && shift > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// the fifth byte holds the last 4 bits
+		if shift == 28 && c > 0x0f {
+			return 0, errULEB128
+		}
+		v |= uint32(c&0x7f) << shift
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+}
+
+// AppendULEB128 appends v in unsigned LEB128 form to b.
+func AppendULEB128(b []byte, v uint32) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
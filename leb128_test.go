@@ -0,0 +1,51 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestULEB128(t *testing.T) {
+	tests := []struct {
+		v uint32
+		b []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16256, []byte{0x80, 0x7f}},
+		{624485, []byte{0xe5, 0x8e, 0x26}},
+		{0xffffffff, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+	}
+	for _, tt := range tests {
+		if got := AppendULEB128([]byte{'x'}, tt.v); !bytes.Equal(got[1:], tt.b) || got[0] != 'x' {
+			t.Errorf("AppendULEB128(%d) = %x, want %x", tt.v, got[1:], tt.b)
+		}
+		r := bytes.NewReader(append(tt.b, 'y'))
+		if got, err := ReadULEB128(r); got != tt.v || err != nil || r.Len() != 1 {
+			t.Errorf("ReadULEB128(%x) = %d, %v; want %d", tt.b, got, err, tt.v)
+		}
+	}
+}
+
+func TestReadULEB128Errors(t *testing.T) {
+	tests := []struct {
+		b   []byte
+		err error
+	}{
+		{nil, io.EOF},
+		{[]byte{0x80}, io.ErrUnexpectedEOF},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x10}, errULEB128},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, errULEB128},
+	}
+	for _, tt := range tests {
+		if _, err := ReadULEB128(bytes.NewReader(tt.b)); err != tt.err {
+			t.Errorf("ReadULEB128(%x) error = %v, want %v", tt.b, err, tt.err)
+		}
+	}
+}
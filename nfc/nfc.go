@@ -0,0 +1,72 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package nfc compares modified UTF-8 strings under Unicode normalization
+// form C, so that an identifier in composed form equals the same identifier
+// in decomposed form.
+package nfc
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/anders/jutf/charset"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Compare orders a and b by the code points of their NFC forms, returning
+// -1, 0 or +1. They are decoded and normalized incrementally, so that only
+// as much is decoded as it takes to find a difference. Raw NULs and 4-byte
+// sequences are accepted, and other malformed sequences are compared as
+// U+FFFD.
+func Compare(a, b []byte) int {
+	if bytes.Equal(a, b) {
+		return 0
+	}
+
+	// the order of UTF-8 bytes is that of code points
+	ra, rb := newReader(a), newReader(b)
+	var bufA, bufB [256]byte
+	var pa, pb []byte
+	var eofA, eofB bool
+	for {
+		if len(pa) == 0 && !eofA {
+			n, err := ra.Read(bufA[:])
+			pa, eofA = bufA[:n], err != nil
+		}
+		if len(pb) == 0 && !eofB {
+			n, err := rb.Read(bufB[:])
+			pb, eofB = bufB[:n], err != nil
+		}
+
+		switch {
+		case len(pa) == 0 && !eofA, len(pb) == 0 && !eofB:
+			continue
+		case len(pa) == 0 && len(pb) == 0:
+			return 0
+		case len(pa) == 0:
+			return -1
+		case len(pb) == 0:
+			return +1
+		}
+
+		n := min(len(pa), len(pb))
+		if c := bytes.Compare(pa[:n], pb[:n]); c != 0 {
+			return c
+		}
+		pa, pb = pa[n:], pb[n:]
+	}
+}
+
+// Equal reports whether a and b decode to the same string under NFC, as
+// Compare would return 0.
+func Equal(a, b []byte) bool {
+	return Compare(a, b) == 0
+}
+
+// newReader returns a reader of the NFC form of the decoding of d.
+func newReader(d []byte) io.Reader {
+	t := transform.Chain(charset.ModifiedUTF8.NewDecoder(), norm.NFC)
+	return transform.NewReader(bytes.NewReader(d), t)
+}
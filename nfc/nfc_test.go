@@ -0,0 +1,43 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package nfc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anders/jutf"
+)
+
+func TestCompare(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+	tests := []struct {
+		a, b []byte
+		want int
+	}{
+		{nil, nil, 0},
+		{nil, []byte("a"), -1},
+		{[]byte("abc"), []byte("abd"), -1},
+		{[]byte("caf\u00e9"), []byte("cafe\u0301"), 0},
+		{[]byte("caf\u00e9s"), []byte("cafe\u0301"), +1},
+		{jutf.Encode("\uac01\x00"), jutf.Encode("\u1100\u1161\u11a8\x00"), 0}, // Hangul
+		{jutf.Encode("\u212b"), []byte("A\u030a"), 0},                         // Angstrom sign
+		{jutf.Encode("\U0001f4a9"), []byte("\U0001f4a9"), 0},
+		{jutf.Encode("\U0001f4a9"), []byte("\uffff"), +1},
+		{[]byte("a\xff"), []byte("a\ufffd"), 0},
+		{[]byte(long + "e\u0301"), []byte(long + "\u00e9"), 0},
+		{[]byte(long + "e\u0301"), []byte(long + "\u00e9x"), -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+		if got := Equal(tt.a, tt.b); got != (tt.want == 0) {
+			t.Errorf("Equal(%q, %q) = %v", tt.a, tt.b, got)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// ErrTooLarge is reported when the input or output exceeds the limit set by
+// MaxLen or MaxDecodedLen.
+var ErrTooLarge = errors.New("data too large")
+
+// An Option changes how Encode and Decode treat their input. Options that do
+// not apply to a function are ignored by it.
+type Option func(*options)
+
+type options struct {
+	strict     bool
+	surrogates bool
+	rawNUL     bool
+	keepSurr   bool
+	onError    OnError
+	zeroCopy   bool
+	stripBOM   bool
+	maxLen     int
+	maxDecoded int
+
+	// accept standard UTF-8 forms anywhere, for streams where it's not
+	// possible to tell if all of the input is standard UTF-8.
+	std bool
+}
+
+func newOptions(opts []Option) options {
+	if len(opts) == 0 {
+		return options{}
+	}
+
+	// the options are closures, so o escapes; keep that from happening to
+	// the caller's copy, and in the common case of no options at all.
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return *o
+}
+
+// Strict makes Decode accept canonical modified UTF-8 only. Without it, input
+// that is valid standard UTF-8 is returned as is, even though it may contain
+// raw NUL bytes or 4-byte sequences.
+func Strict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// LenientSurrogates makes Decode replace unpaired surrogates with U+FFFD
+// rather than failing.
+func LenientSurrogates() Option {
+	return func(o *options) { o.surrogates = true }
+}
+
+// RawNUL makes Decode accept raw NUL bytes, and Encode output them rather
+// than the 2-byte form.
+func RawNUL() Option {
+	return func(o *options) { o.rawNUL = true }
+}
+
+// KeepSurrogates makes Encode keep unpaired surrogates in its input, which is
+// then WTF-8 such as from Java chars, in their 3-byte form rather than
+// replacing each of their bytes with U+FFFD. Data that started out as Java
+// chars then encodes the way Java would, byte for byte.
+func KeepSurrogates() Option {
+	return func(o *options) { o.keepSurr = true }
+}
+
+// Lossy makes Decode replace every malformed byte with U+FFFD. Decode never
+// fails in this mode, except for exceeding MaxLen. It is the same as
+// ErrorPolicy(Replace).
+func Lossy() Option {
+	return ErrorPolicy(Replace)
+}
+
+// OnError is what to do with malformed input. It is given to Decode, DecodeTo
+// and the like, and to a Decoder, with ErrorPolicy, and to the encodings in
+// the charset package.
+type OnError int
+
+const (
+	Fail        OnError = iota // report a *DecodeError
+	Replace                    // replace each malformed sequence with U+FFFD
+	Skip                       // leave malformed sequences out
+	Passthrough                // copy malformed sequences to the output as is
+)
+
+func (p OnError) String() string {
+	switch p {
+	case Fail:
+		return "Fail"
+	case Replace:
+		return "Replace"
+	case Skip:
+		return "Skip"
+	case Passthrough:
+		return "Passthrough"
+	}
+	return "OnError(" + strconv.Itoa(int(p)) + ")"
+}
+
+// ErrorPolicy makes Decode handle malformed input as p says. The default is
+// Fail. Note that with Passthrough, the output is not valid UTF-8 if the
+// input is malformed.
+func ErrorPolicy(p OnError) Option {
+	return func(o *options) { o.onError = p }
+}
+
+// MaxLen makes Decode fail with ErrTooLarge when the input is longer than n
+// bytes. Zero means no limit.
+func MaxLen(n int) Option {
+	return func(o *options) { o.maxLen = n }
+}
+
+// MaxDecodedLen makes Decode fail with ErrTooLarge when the output would be
+// longer than n bytes, counting malformed input as U+FFFD. This is checked
+// before anything is allocated. Zero means no limit.
+func MaxDecodedLen(n int) Option {
+	return func(o *options) { o.maxDecoded = n }
+}
+
+// checkLimits returns an error if d exceeds the limits in o.
+func (o *options) checkLimits(d []byte) error {
+	if o.maxLen > 0 && len(d) > o.maxLen {
+		return newDecodeError(d, o.maxLen, ErrTooLarge)
+	}
+
+	// the output is never longer than the input, except when Lossy
+	// replaces single bytes with U+FFFD.
+	if o.maxDecoded > 0 && (len(d) > o.maxDecoded || o.onError == Replace) {
+		if at := overLimit(d, o.maxDecoded); at >= 0 {
+			return newDecodeError(d, at, ErrTooLarge)
+		}
+	}
+	return nil
+}
+
+// ZeroCopy makes Decode return a string sharing memory with its input when no
+// transformation is needed, which is the case for most real-world strings.
+// The caller must then not modify the input for as long as the string is in
+// use, as that would also change the supposedly immutable string.
+func ZeroCopy() Option {
+	return func(o *options) { o.zeroCopy = true }
+}
+
+// StripBOM makes decoding drop a byte order mark, U+FEFF, at the start of
+// the input, as some Java exporters write one. It applies to everything that
+// takes decoding options except ReadUTF and its variants, and for a Decoder
+// or SeekDecoder to the start of the stream. The offsets in a *DecodeError
+// and of a SeekDecoder still count it.
+func StripBOM() Option {
+	return func(o *options) { o.stripBOM = true }
+}
+
+// bom is the encoding of U+FEFF, which is the same in modified UTF-8.
+var bom = []byte{0xef, 0xbb, 0xbf}
+
+// bomLen returns the length of the byte order mark at the start of d that
+// is to be dropped.
+func (o *options) bomLen(d []byte) int {
+	if o.stripBOM && bytes.HasPrefix(d, bom) {
+		return len(bom)
+	}
+	return 0
+}
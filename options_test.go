@@ -0,0 +1,250 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts []Option
+		want string
+		err  error
+	}{
+		{"default UTF-8", []byte("a\x00\U0001f4a9"), nil, "a\x00\U0001f4a9", nil},
+		{"strict UTF-8", []byte("a\x00"), []Option{Strict()}, "", ErrInvalidNUL},
+		{"strict four byte", []byte("\U0001f4a9"), []Option{Strict()}, "", ErrFourByte},
+		{"strict", []byte{'a', 0xc0, 0x80}, []Option{Strict()}, "a\x00", nil},
+		{"raw NUL", []byte{0, 0xc0, 0x80}, nil, "", ErrInvalidNUL},
+		{"mixed", []byte{'a', 0xf0, 0x9f, 0x92, 0xa9, 0xc0, 0x80}, nil, "", ErrFourByte},
+		{"mixed lossy", []byte{'a', 0xf0, 0x9f, 0x92, 0xa9, 0xc0, 0x80}, []Option{Lossy()}, "a�\x00", nil},
+		{"raw NUL allowed", []byte{0, 0xc0, 0x80}, []Option{RawNUL()}, "\x00\x00", nil},
+		{"lone high", []byte{0xed, 0xa0, 0xbd, 'a', 'b', 'c'}, nil, "", ErrUnpairedSurrogate},
+		{"lone high at end", []byte{0xed, 0xa0, 0xbd}, []Option{LenientSurrogates()}, "�", nil},
+		{"lone low", []byte{'a', 0xed, 0xb2, 0xa9, 0xc0, 0x80}, []Option{LenientSurrogates()}, "a�\x00", nil},
+		{"lenient garbage", []byte{0xff, 0xc0, 0x80}, []Option{LenientSurrogates()}, "", ErrInvalidEncoding},
+		{"lossy", []byte{0xff, 'a', 0xed, 0xa0, 0xbd, 0xc0, 0x80, 0xe6}, []Option{Lossy()}, "�a�\x00�", nil},
+		{"overlong", []byte{0xc1, 0x81, 0xc0, 0x80}, nil, "", ErrInvalidEncoding},
+		{"max len", []byte("abcd"), []Option{MaxLen(3)}, "", ErrTooLarge},
+		{"max len ok", []byte("abc"), []Option{MaxLen(3)}, "abc", nil},
+		{"max decoded", Encode("ab\U0001f4a9"), []Option{MaxDecodedLen(5)}, "", ErrTooLarge},
+		{"max decoded ok", Encode("ab\U0001f4a9"), []Option{MaxDecodedLen(6)}, "ab\U0001f4a9", nil},
+		{"max decoded lossy", []byte{'a', 0xff}, []Option{Lossy(), MaxDecodedLen(3)}, "", ErrTooLarge},
+		{"skip everything", []byte{0xff}, []Option{ErrorPolicy(Skip)}, "", nil},
+		{"skip lone surrogate", []byte{0xed, 0xa0, 0x80}, []Option{ErrorPolicy(Skip)}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.data, tt.opts...)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("Decode() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestErrorPolicy(t *testing.T) {
+	data := []byte{0xff, 'a', 0xed, 0xa0, 0xbd, 0xc0, 0x80, 0xe6}
+	tests := []struct {
+		policy OnError
+		want   string
+		err    error
+	}{
+		{Fail, "", ErrInvalidEncoding},
+		{Replace, "\ufffda\ufffd\x00\ufffd", nil},
+		{Skip, "a\x00", nil},
+		{Passthrough, "\xffa\xed\xa0\xbd\x00\xe6", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			opt := ErrorPolicy(tt.policy)
+			if got, err := Decode(data, opt); got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("Decode() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+
+			var buf bytes.Buffer
+			if _, err := DecodeTo(&buf, data, opt); !errors.Is(err, tt.err) || err == nil && buf.String() != tt.want {
+				t.Errorf("DecodeTo() = %q, %v; want %q, %v", buf.String(), err, tt.want, tt.err)
+			}
+
+			got, err := io.ReadAll(NewDecoder(iotest.OneByteReader(bytes.NewReader(data)), opt))
+			if !errors.Is(err, tt.err) || err == nil && string(got) != tt.want {
+				t.Errorf("Decoder = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+
+	if s := OnError(9).String(); s != "OnError(9)" {
+		t.Errorf("OnError(9).String() = %q", s)
+	}
+}
+
+func TestKeepSurrogates(t *testing.T) {
+	// a WTF-8 string from the Java chars D83D 'a' DCA9 D83D
+	wtf := "\xed\xa0\xbda\xed\xb2\xa9\xed\xa0\xbd\x00\U0001f4a9"
+	want := FromUTF16([]uint16{0xd83d, 'a', 0xdca9, 0xd83d, 0, 0xd83d, 0xdca9})
+
+	if got := Encode(wtf, KeepSurrogates()); !bytes.Equal(got, want) {
+		t.Errorf("Encode(KeepSurrogates()) = %x, want %x", got, want)
+	}
+	if got := EncodeToString(wtf, KeepSurrogates()); got != string(want) {
+		t.Errorf("EncodeToString(KeepSurrogates()) = %x, want %x", got, want)
+	}
+	if got := EncodeAll([]string{wtf}, KeepSurrogates()); !bytes.Equal(got[0], want) || cap(got[0]) != len(want) {
+		t.Errorf("EncodeAll(KeepSurrogates()) = %x, want %x", got[0], want)
+	}
+	if got := Encode(wtf); bytes.Count(got, []byte("\ufffd")) != 9 {
+		t.Errorf("Encode() = %x, want 9 replacements", got)
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, KeepSurrogates())
+	for i := 0; i < len(wtf); i++ {
+		e.Write([]byte{wtf[i]})
+	}
+	e.Close()
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Encoder with KeepSurrogates() = %x, want %x", buf.Bytes(), want)
+	}
+}
+
+func TestStripBOM(t *testing.T) {
+	tests := []struct {
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{[]byte("\ufeffabc"), "abc", false},
+		{Encode("\ufeffa\x00b"), "a\x00b", false},
+		{[]byte("\ufeff"), "", false},
+		{[]byte("a\ufeff"), "a\ufeff", false},
+		{[]byte("\ufeff\ufeff"), "\ufeff", false},
+		{[]byte{0xef, 0xbb}, "", true},
+	}
+	for _, tt := range tests {
+		if got, err := Decode(tt.data, StripBOM()); got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Decode(%x, StripBOM()) = %q, %v; want %q", tt.data, got, err, tt.want)
+		}
+		if got, err := DecodeToBytes(tt.data, StripBOM(), ZeroCopy()); string(got) != tt.want && !tt.wantErr || (err != nil) != tt.wantErr {
+			t.Errorf("DecodeToBytes(%x, StripBOM()) = %q, %v; want %q", tt.data, got, err, tt.want)
+		}
+
+		var buf bytes.Buffer
+		if _, err := DecodeTo(&buf, tt.data, StripBOM()); buf.String() != tt.want && !tt.wantErr || (err != nil) != tt.wantErr {
+			t.Errorf("DecodeTo(%x, StripBOM()) = %q, %v; want %q", tt.data, buf.String(), err, tt.want)
+		}
+
+		got, err := io.ReadAll(NewDecoder(iotest.OneByteReader(bytes.NewReader(tt.data)), StripBOM()))
+		if string(got) != tt.want && !tt.wantErr || (err != nil) != tt.wantErr {
+			t.Errorf("Decoder(%x, StripBOM()) = %q, %v; want %q", tt.data, got, err, tt.want)
+		}
+	}
+
+	if got, _ := Decode([]byte("\ufeffabc")); got != "\ufeffabc" {
+		t.Errorf("Decode() = %q, want the byte order mark kept", got)
+	}
+
+	// the other ways of decoding
+	d := []byte("\ufeffabc\xc0\x80")
+	if got, err := NewCodec(StripBOM()).Decode(d); got != "abc\x00" || err != nil {
+		t.Errorf("Codec.Decode(StripBOM()) = %q, %v", got, err)
+	}
+	if got, err := DecodeAll([][]byte{d, d[3:]}, StripBOM()); err != nil || len(got) != 2 || got[0] != "abc\x00" || got[1] != "abc\x00" {
+		t.Errorf("DecodeAll(StripBOM()) = %q, %v", got, err)
+	}
+	if got, st, err := DecodeWithStats(d[:6], StripBOM()); got != "abc" || err != nil || st.Rewritten != 3 || !st.Changed() {
+		t.Errorf("DecodeWithStats(StripBOM()) = %q, %+v, %v", got, st, err)
+	}
+	if got, st, err := DecodeWithStats(d, StripBOM()); got != "abc\x00" || err != nil || st.Rewritten != 5 || st.NULs != 1 {
+		t.Errorf("DecodeWithStats(StripBOM()) = %q, %+v, %v", got, st, err)
+	}
+
+	sd := NewSeekDecoder(bytes.NewReader([]byte("\ufeffabcdef")), StripBOM())
+	if _, err := sd.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(sd); string(got) != "ef" || err != nil {
+		t.Errorf("SeekDecoder(StripBOM()) read %q, %v after Seek(4)", got, err)
+	}
+	for enc, want := range []int64{0, 0, 0, 0, 1, 2} {
+		if dec, err := sd.DecodedOffset(int64(enc)); dec != want || err != nil {
+			t.Errorf("SeekDecoder(StripBOM()).DecodedOffset(%d) = %d, %v; want %d", enc, dec, err, want)
+		}
+	}
+	for dec, want := range []int64{3, 4, 5} {
+		if enc, err := sd.EncodedOffset(int64(dec)); enc != want || err != nil {
+			t.Errorf("SeekDecoder(StripBOM()).EncodedOffset(%d) = %d, %v; want %d", dec, enc, err, want)
+		}
+	}
+
+	// back to the start, over the byte order mark
+	for _, off := range []int64{0, 1} {
+		if _, err := sd.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := io.ReadAll(sd); string(got) != "abcdef"[off:] || err != nil {
+			t.Errorf("SeekDecoder(StripBOM()) read %q, %v after Seek(%d)", got, err, off)
+		}
+	}
+
+	// offsets count the byte order mark
+	var e *DecodeError
+	if _, err := Decode([]byte("\ufeffa\xff"), StripBOM()); !errors.As(err, &e) || e.Offset != 4 {
+		t.Errorf("Decode(StripBOM()) error = %v, want offset 4", err)
+	}
+	_, err := io.ReadAll(NewDecoder(bytes.NewReader([]byte("\ufeffa\xff")), StripBOM()))
+	if !errors.As(err, &e) || e.Offset != 4 {
+		t.Errorf("Decoder(StripBOM()) error = %v, want offset 4", err)
+	}
+}
+
+func TestEncodeRawNUL(t *testing.T) {
+	if got := string(Encode("a\x00b", RawNUL())); got != "a\x00b" {
+		t.Errorf("Encode() = %q, want %q", got, "a\x00b")
+	}
+}
+
+func TestDecodeZeroCopy(t *testing.T) {
+	d := []byte("java/lang/Object")
+	s, err := Decode(d, ZeroCopy())
+	if err != nil || s != "java/lang/Object" {
+		t.Fatalf("Decode() = %q, %v", s, err)
+	}
+
+	// the string shares memory with d
+	d[0] = 'J'
+	if s != "Java/lang/Object" {
+		t.Errorf("Decode() = %q, input was copied", s)
+	}
+
+	if s, err := Decode(Encode("a\x00b"), ZeroCopy()); err != nil || s != "a\x00b" {
+		t.Errorf("Decode() = %q, %v", s, err)
+	}
+}
+
+func TestDecodeLimitOffset(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		limit  int
+		offset int
+	}{
+		{[]byte("abcdefghijklmnopqrstuvwxyz"), 20, 20},
+		{Encode("ab\x00\U0001f4a9c"), 6, 4},
+		{Encode("ab\x00\U0001f4a9c"), 7, 10},
+	}
+	for _, tt := range tests {
+		var de *DecodeError
+		_, err := Decode(tt.data, MaxDecodedLen(tt.limit))
+		if !errors.As(err, &de) || de.Offset != tt.offset {
+			t.Errorf("Decode(%q) error = %v, want offset %d", tt.data, err, tt.offset)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// inputs smaller than this are not worth splitting up.
+const minChunk = 256 << 10
+
+// EncodeParallel is like Encode, but splits large inputs into chunks that
+// are encoded concurrently.
+func EncodeParallel(s string, opts ...Option) []byte {
+	bounds := split(len(s), func(i int) bool {
+		// any rune start, invalid bytes are encoded one at a time
+		return s[i]&0xc0 != 0x80
+	})
+	if len(bounds) <= 2 {
+		return Encode(s, opts...)
+	}
+
+	o := newOptions(opts)
+
+	// size the chunks first, so that the output is a single allocation
+	offsets := make([]int, len(bounds))
+	parallel(len(bounds)-1, func(k int) {
+		offsets[k+1] = encodedLen(s[bounds[k]:bounds[k+1]], &o)
+	})
+	for k := 1; k < len(offsets); k++ {
+		offsets[k] += offsets[k-1]
+	}
+
+	out := make([]byte, offsets[len(offsets)-1])
+	parallel(len(bounds)-1, func(k int) {
+		dst := out[offsets[k]:offsets[k]:offsets[k+1]]
+		encode(dst, s[bounds[k]:bounds[k+1]], &o)
+	})
+
+	return out
+}
+
+// DecodeParallel is like Decode, but splits large inputs into chunks that
+// are decoded concurrently. The result, including any error, is the same.
+func DecodeParallel(d []byte, opts ...Option) (string, error) {
+	bounds := split(len(d), func(i int) bool {
+		// any sequence start, other than the second half of a surrogate
+		// pair
+		c := d[i]
+		if c == 0xed && i >= 3 && d[i-3] == 0xed && d[i-2]&0xf0 == 0xa0 {
+			return false
+		}
+		return c < 0x80 || c >= 0xc0
+	})
+
+	o := newOptions(opts)
+	if len(bounds) <= 2 {
+		return Decode(d, opts...)
+	} else if err := o.checkLimits(d); err != nil {
+		return "", err
+	}
+	bounds[0] = o.bomLen(d)
+
+	chunks := make([]struct {
+		out   []byte
+		plain bool
+		err   error
+	}, len(bounds)-1)
+
+	// if all chunks decode to themselves, so does d
+	parallel(len(chunks), func(k int) {
+		start, end := bounds[k], bounds[k+1]
+		if chunks[k].plain = same(d, start, end, &o) == end; !chunks[k].plain {
+			chunks[k].out, chunks[k].err = decode(make([]byte, 0, end-start), d, start, end, &o)
+		}
+	})
+
+	plain := true
+	for _, c := range chunks {
+		plain = plain && c.plain
+	}
+	if plain {
+		if d = d[bounds[0]:]; o.zeroCopy {
+			return unsafe.String(&d[0], len(d)), nil
+		}
+		return string(d), nil
+	}
+
+	// otherwise standard UTF-8 forms in plain chunks must be decoded too
+	parallel(len(chunks), func(k int) {
+		if start, end := bounds[k], bounds[k+1]; chunks[k].plain {
+			chunks[k].out, chunks[k].err = decode(make([]byte, 0, end-start), d, start, end, &o)
+		}
+	})
+
+	n := 0
+	for _, c := range chunks {
+		if c.err != nil {
+			return "", c.err
+		}
+		n += len(c.out)
+	}
+
+	if n == 0 {
+		// Skip may have left nothing
+		return "", nil
+	}
+	buf := make([]byte, 0, n)
+	for _, c := range chunks {
+		buf = append(buf, c.out...)
+	}
+	return unsafe.String(&buf[0], len(buf)), nil
+}
+
+// split divides n bytes of input into up to GOMAXPROCS chunks of at least
+// minChunk bytes each, returning their bounds. Chunks start at an index for
+// which start returns true.
+func split(n int, start func(i int) bool) []int {
+	k := runtime.GOMAXPROCS(0)
+	if max := n / minChunk; k > max {
+		k = max
+	}
+
+	bounds := []int{0}
+	for i := 1; i < k; i++ {
+		at := n / k * i
+		for at < n && !start(at) {
+			at++
+		}
+		if at > bounds[len(bounds)-1] && at < n {
+			bounds = append(bounds, at)
+		}
+	}
+	return append(bounds, n)
+}
+
+// parallel calls fn(0) to fn(n-1) in separate goroutines and waits for them.
+func parallel(n int, fn func(k int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for k := 0; k < n; k++ {
+		go func(k int) {
+			defer wg.Done()
+			fn(k)
+		}(k)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,49 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	// long enough for 4 chunks, with the pieces straddling the bounds
+	text := strings.Repeat("Hello\x00Wörld!!! 日本語 \U0001f4a9", 4*minChunk/40+1)
+	inputs := map[string][]byte{
+		"UTF-8":        []byte(text),
+		"modified":     Encode(text),
+		"mixed":        append(Encode(text), text...),
+		"error":        append(Encode(text), 0xed, 0xa0, 0xbd, 'a'),
+		"cut off":      append(Encode(text), 0xe6, 0x97),
+		"garbage":      bytes.Repeat([]byte{0xff}, 4*minChunk),
+		"BOM":          append([]byte("\ufeff"), text...),
+		"BOM modified": append([]byte("\ufeff"), Encode(text)...),
+	}
+
+	for name, s := range map[string]string{"text": text, "invalid": text + "\xff"} {
+		for _, opts := range [][]Option{nil, {RawNUL()}} {
+			if got, want := EncodeParallel(s, opts...), Encode(s, opts...); !bytes.Equal(got, want) {
+				t.Errorf("EncodeParallel(%s, %d options) differs from Encode()", name, len(opts))
+			}
+		}
+	}
+
+	for name, d := range inputs {
+		for _, opts := range [][]Option{nil, {Lossy()}, {Strict()}, {RawNUL()}, {ErrorPolicy(Skip)}, {StripBOM()}, {StripBOM(), ZeroCopy()}} {
+			got, gotErr := DecodeParallel(d, opts...)
+			want, wantErr := Decode(d, opts...)
+
+			var ge, we *DecodeError
+			if got != want || errors.As(gotErr, &ge) != errors.As(wantErr, &we) || ge != nil && ge.Error() != we.Error() {
+				t.Errorf("DecodeParallel(%s) = %d bytes, %v; want %d bytes, %v", name, len(got), gotErr, len(want), wantErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Profile is a variant of the encoding, for applications that choose how
+// to interoperate through configuration. The predefined profiles can be
+// found by name with LookupProfile.
+type Profile interface {
+	Name() string
+	Encode(s string) []byte
+	Decode(d []byte) (string, error)
+	Valid(d []byte) bool
+}
+
+// Predefined profiles.
+var (
+	// Canonical is modified UTF-8 as written by the JVM, decoded strictly.
+	Canonical Profile = &profile{"canonical",
+		func(s string) []byte { return Encode(s) },
+		func(d []byte) (string, error) { return Decode(d, Strict()) },
+		Valid,
+	}
+
+	// OpenJDKLenient decodes the way DataInputStream.readUTF does, see
+	// DecodeJava.
+	OpenJDKLenient Profile = &profile{"openjdk-lenient",
+		func(s string) []byte { return Encode(s) },
+		DecodeJava,
+		func(d []byte) bool { _, err := decodeJava(d); return err == nil },
+	}
+
+	// CESU8 is CESU-8, which encodes supplementary characters as surrogate
+	// pairs like modified UTF-8, but NUL as a single 0 byte.
+	CESU8 Profile = &profile{"cesu-8",
+		func(s string) []byte { return Encode(s, RawNUL()) },
+		decodeCESU8,
+		func(d []byte) bool { at, _ := checkForms(d, false); return at < 0 },
+	}
+
+	// WTF8 is WTF-8, standard UTF-8 that allows unpaired surrogates. They
+	// decode to U+FFFD, since Go strings cannot hold them.
+	WTF8 Profile = &profile{"wtf-8",
+		encodeWTF8,
+		decodeWTF8,
+		func(d []byte) bool { at, _ := checkForms(d, true); return at < 0 },
+	}
+)
+
+type profile struct {
+	name   string
+	encode func(string) []byte
+	decode func([]byte) (string, error)
+	valid  func([]byte) bool
+}
+
+func (p *profile) Name() string                    { return p.name }
+func (p *profile) Encode(s string) []byte          { return p.encode(s) }
+func (p *profile) Decode(d []byte) (string, error) { return p.decode(d) }
+func (p *profile) Valid(d []byte) bool             { return p.valid(d) }
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]Profile{}
+)
+
+func init() {
+	for _, p := range []Profile{Canonical, OpenJDKLenient, CESU8, WTF8} {
+		RegisterProfile(p)
+	}
+}
+
+// RegisterProfile makes p available to LookupProfile under its name. It
+// panics if a profile of that name is already registered.
+func RegisterProfile(p Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+
+	name := strings.ToLower(p.Name())
+	if _, dup := profiles[name]; dup {
+		panic("jutf: RegisterProfile called twice for " + name)
+	}
+	profiles[name] = p
+}
+
+// LookupProfile returns the profile with the given name, which is matched
+// without regard to case.
+func LookupProfile(name string) (Profile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	p, ok := profiles[strings.ToLower(name)]
+	return p, ok
+}
+
+// checkForms returns the offset of the first sequence of d that is not
+// allowed by CESU-8 or, if wtf is set, WTF-8, along with the reason. The
+// offset is -1 if there is none.
+func checkForms(d []byte, wtf bool) (int, error) {
+	for i := 0; i < len(d); {
+		if i += asciiSpan(d[i:]); i == len(d) {
+			break
+		}
+
+		n, err := scan(d[i:])
+		switch {
+		case err == ErrInvalidNUL:
+			// both use raw NULs
+		case err == nil && d[i] == 0xc0:
+			return i, ErrInvalidEncoding
+		case !wtf && err != nil:
+			return i, err
+		case wtf && err == nil && n == 6:
+			// a pair must be the 4-byte form in WTF-8
+			return i, ErrInvalidEncoding
+		case wtf && err == ErrTooShortSurrogate && n > 3:
+			// a high surrogate, allowed alone, followed by a cut off sequence
+			return i + 3, ErrTooShort
+		case err != nil && err != ErrFourByte && err != ErrUnpairedSurrogate && err != ErrTooShortSurrogate:
+			return i, err
+		}
+		i += n
+	}
+	return -1, nil
+}
+
+func decodeCESU8(d []byte) (string, error) {
+	if at, err := checkForms(d, false); at >= 0 {
+		return "", newDecodeError(d, at, err)
+	}
+	return Decode(d, RawNUL())
+}
+
+// encodeWTF8 encodes s as WTF-8. Surrogates in their 3-byte form are kept as
+// with KeepSurrogates, except that a pair of them is joined into the 4-byte
+// form of its character. Other invalid bytes become U+FFFD each.
+func encodeWTF8(s string) []byte {
+	dst := make([]byte, 0, len(s))
+	last := 0 // start of the run not yet copied to dst
+
+	for i := 0; i < len(s); {
+		if r, n := utf8.DecodeRuneInString(s[i:]); r != utf8.RuneError || n > 1 {
+			i += n
+			continue
+		}
+
+		dst = append(dst, s[last:i]...)
+		switch n := surrogateLen(s[i:]); {
+		case n > 0 && s[i+1] < 0xb0 && surrogateLen(s[i+3:]) > 0 && s[i+4] >= 0xb0:
+			dst = utf8.AppendRune(dst, decodePair(stringBytes(s[i:i+6])))
+			i += 6
+		case n > 0:
+			dst = append(dst, s[i:i+n]...)
+			i += n
+		default:
+			dst = append(dst, "\ufffd"...)
+			i++
+		}
+		last = i
+	}
+
+	return append(dst, s[last:]...)
+}
+
+func decodeWTF8(d []byte) (string, error) {
+	if at, err := checkForms(d, true); at >= 0 {
+		return "", newDecodeError(d, at, err)
+	}
+	o := options{std: true, surrogates: true}
+	return decodeString(d, &o)
+}
@@ -0,0 +1,128 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLookupProfile(t *testing.T) {
+	for _, name := range []string{"canonical", "OpenJDK-Lenient", "CESU-8", "wtf-8"} {
+		if p, ok := LookupProfile(name); !ok || !bytes.EqualFold([]byte(p.Name()), []byte(name)) {
+			t.Errorf("LookupProfile(%q) = %v, %v", name, p, ok)
+		}
+	}
+	if _, ok := LookupProfile("utf-8"); ok {
+		t.Errorf("LookupProfile(utf-8) found a profile")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterProfile() of a duplicate did not panic")
+		}
+	}()
+	RegisterProfile(&profile{name: "CANONICAL"})
+}
+
+func TestProfiles(t *testing.T) {
+	pair := []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}
+	tests := []struct {
+		name string
+		data []byte
+		want []string // decoded by Canonical, OpenJDKLenient, CESU8 and WTF8, "!" for an error
+	}{
+		{"ascii", []byte("abc"), []string{"abc", "abc", "abc", "abc"}},
+		{"modified NUL", []byte{'a', 0xc0, 0x80}, []string{"a\x00", "a\x00", "!", "!"}},
+		{"raw NUL", []byte{'a', 0}, []string{"!", "a\x00", "a\x00", "a\x00"}},
+		{"pair", pair, []string{"\U0001f4a9", "\U0001f4a9", "\U0001f4a9", "!"}},
+		{"four byte", []byte("\U0001f4a9"), []string{"!", "!", "!", "\U0001f4a9"}},
+		{"raw NUL and four byte", []byte("\x00\U0001f4a9"), []string{"!", "!", "!", "\x00\U0001f4a9"}},
+		{"lone high", []byte{'a', 0xed, 0xa0, 0xbd}, []string{"!", "a\ufffd", "!", "a\ufffd"}},
+		{"lone low", []byte{0xed, 0xb2, 0xa9, 'b'}, []string{"!", "\ufffdb", "!", "\ufffdb"}},
+		{"high and partial", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2}, []string{"!", "!", "!", "!"}},
+		{"invalid", []byte{'a', 0xff}, []string{"!", "!", "!", "!"}},
+	}
+	profiles := []Profile{Canonical, OpenJDKLenient, CESU8, WTF8}
+	for _, tt := range tests {
+		for k, p := range profiles {
+			got, err := p.Decode(tt.data)
+			if err != nil {
+				got = "!"
+			}
+			if got != tt.want[k] {
+				t.Errorf("%s: %s.Decode(%x) = %q, %v; want %q", tt.name, p.Name(), tt.data, got, err, tt.want[k])
+			}
+			if valid := p.Valid(tt.data); valid != (tt.want[k] != "!") {
+				t.Errorf("%s: %s.Valid(%x) = %v", tt.name, p.Name(), tt.data, valid)
+			}
+		}
+	}
+}
+
+func TestWTF8DecodeError(t *testing.T) {
+	tests := []struct {
+		data []byte
+		at   int
+		err  error
+	}{
+		{[]byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, 0, ErrInvalidEncoding},
+		{[]byte{'a', 0xed, 0xa0, 0xbd, 0xed, 0xb2}, 4, ErrTooShort},
+		{[]byte{0xed, 0xa0, 0xbd, 0xed}, 3, ErrTooShort},
+		{[]byte{'a', 0xed, 0xa0}, 1, ErrTooShort},
+	}
+	for _, tt := range tests {
+		_, err := WTF8.Decode(tt.data)
+		var de *DecodeError
+		if !errors.As(err, &de) || de.Offset != tt.at || de.Err != tt.err {
+			t.Errorf("WTF8.Decode(%x) error = %v, want %v at offset %d", tt.data, err, tt.err, tt.at)
+		}
+	}
+}
+
+func TestProfileEncode(t *testing.T) {
+	s := "a\x00\U0001f4a9"
+	tests := []struct {
+		p    Profile
+		want string
+	}{
+		{Canonical, "a\xc0\x80\xed\xa0\xbd\xed\xb2\xa9"},
+		{OpenJDKLenient, "a\xc0\x80\xed\xa0\xbd\xed\xb2\xa9"},
+		{CESU8, "a\x00\xed\xa0\xbd\xed\xb2\xa9"},
+		{WTF8, s},
+	}
+	for _, tt := range tests {
+		got := tt.p.Encode(s)
+		if string(got) != tt.want {
+			t.Errorf("%s.Encode(%q) = %x, want %x", tt.p.Name(), s, got, tt.want)
+		}
+		if back, err := tt.p.Decode(got); back != s || err != nil {
+			t.Errorf("%s.Decode(%x) = %q, %v", tt.p.Name(), got, back, err)
+		}
+	}
+}
+
+func TestWTF8Encode(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"a\x00\U0001f4a9", "a\x00\U0001f4a9"},
+		{"a\xed\xa0\xbdb", "a\xed\xa0\xbdb"},
+		{"\xed\xb2\xa9\xed\xa0\xbd", "\xed\xb2\xa9\xed\xa0\xbd"},
+		{"\xed\xa0\xbd\xed\xb2\xa9", "\U0001f4a9"},
+		{"a\xff\xfeb", "a\ufffd\ufffdb"},
+		{"\xed\xa0", "\ufffd\ufffd"},
+		{"\xed\xa0\xbd\xed\xb2", "\xed\xa0\xbd\ufffd\ufffd"},
+	}
+	for _, tt := range tests {
+		got := WTF8.Encode(tt.s)
+		if string(got) != tt.want {
+			t.Errorf("WTF8.Encode(%x) = %x, want %x", tt.s, got, tt.want)
+		}
+		if at, err := checkForms(got, true); at >= 0 {
+			t.Errorf("WTF8.Encode(%x) = %x, not WTF-8 at %d: %v", tt.s, got, at, err)
+		}
+	}
+}
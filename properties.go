@@ -0,0 +1,105 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errMalformedUnicode = errors.New(`malformed \uxxxx encoding`)
+
+// EscapeProperties returns the encoded string b escaped the way
+// java.util.Properties.store writes a key, if isKey is set, or a value: as
+// ASCII, with chars outside of printable ASCII written as \uXXXX and with
+// the characters that are special in properties files escaped. Chars are
+// escaped one at a time, so unpaired surrogates are kept. Other malformed
+// sequences are written as \uFFFD.
+func EscapeProperties(b []byte, isKey bool) string {
+	const digits = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	sb.Grow(len(b))
+	r := charReader{d: b}
+	for first := true; ; first = false {
+		c, ok := r.next()
+		if !ok {
+			break
+		}
+
+		switch c {
+		case ' ':
+			if first || isKey {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(' ')
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\\', '=', ':', '#', '!':
+			sb.WriteByte('\\')
+			sb.WriteByte(byte(c))
+		default:
+			if c < 0x20 || c > 0x7e {
+				sb.WriteString(`\u`)
+				for shift := 12; shift >= 0; shift -= 4 {
+					sb.WriteByte(digits[c>>shift&0xf])
+				}
+			} else {
+				sb.WriteByte(byte(c))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// UnescapeProperties returns the encoding of a key or value escaped the way
+// java.util.Properties.load reads them from an ISO-8859-1 stream: each byte
+// of s is a char, and \uXXXX, \t, \n, \r and \f are escapes. A backslash
+// before any other char stands for that char. Like Properties, it fails for
+// a \u escape that is not followed by 4 hex digits.
+func UnescapeProperties(s []byte) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			// a trailing backslash is dropped, as by Properties
+			if c != '\\' {
+				b = appendRune(b, rune(c))
+			}
+			continue
+		}
+
+		i++
+		switch c = s[i]; c {
+		case 't':
+			b = append(b, '\t')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 'f':
+			b = append(b, '\f')
+		case 'u':
+			if i+5 > len(s) {
+				return nil, errMalformedUnicode
+			}
+			v, err := strconv.ParseUint(string(s[i+1:i+5]), 16, 16)
+			if err != nil {
+				return nil, errMalformedUnicode
+			}
+			b = appendRune(b, rune(v))
+			i += 4
+		default:
+			b = appendRune(b, rune(c))
+		}
+	}
+	return b, nil
+}
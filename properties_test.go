@@ -0,0 +1,65 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeProperties(t *testing.T) {
+	tests := []struct {
+		in         []byte
+		key, value string
+	}{
+		{nil, "", ""},
+		{[]byte("hello"), "hello", "hello"},
+		{[]byte(" a b"), `\ a\ b`, `\ a b`},
+		{[]byte("a=b:c#d!e\\f"), `a\=b\:c\#d\!e\\f`, `a\=b\:c\#d\!e\\f`},
+		{[]byte("\t\n\r\f\x01\x7f"), `\t\n\r\f\u0001\u007F`, `\t\n\r\f\u0001\u007F`},
+		{Encode("\u00e9\x00\U0001f4a9"), `\u00E9\u0000\uD83D\uDCA9`, `\u00E9\u0000\uD83D\uDCA9`},
+		{[]byte{0xed, 0xa0, 0xbd}, `\uD83D`, `\uD83D`},
+		{[]byte{0xff}, `\uFFFD`, `\uFFFD`},
+	}
+	for _, tt := range tests {
+		if got := EscapeProperties(tt.in, true); got != tt.key {
+			t.Errorf("EscapeProperties(%q, true) = %s, want %s", tt.in, got, tt.key)
+		}
+		if got := EscapeProperties(tt.in, false); got != tt.value {
+			t.Errorf("EscapeProperties(%q, false) = %s, want %s", tt.in, got, tt.value)
+		}
+		if bytes.Equal(tt.in, []byte{0xff}) {
+			continue
+		}
+		for _, s := range []string{tt.key, tt.value} {
+			if got, err := UnescapeProperties([]byte(s)); !bytes.Equal(got, tt.in) || err != nil {
+				t.Errorf("UnescapeProperties(%s) = %q, %v; want %q", s, got, err, tt.in)
+			}
+		}
+	}
+}
+
+func TestUnescapeProperties(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{`\a\b\"`, []byte(`ab"`)},
+		{"caf\xe9", []byte("café")},
+		{"\x00", []byte{0xc0, 0x80}},
+		{`\u00e9\u00E9`, []byte("\u00e9\u00e9")},
+		{`abc\`, []byte("abc")},
+	}
+	for _, tt := range tests {
+		if got, err := UnescapeProperties([]byte(tt.in)); !bytes.Equal(got, tt.want) || err != nil {
+			t.Errorf("UnescapeProperties(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{`\u`, `\u12`, `\u12g4`, `\u+123`} {
+		if got, err := UnescapeProperties([]byte(in)); err == nil {
+			t.Errorf("UnescapeProperties(%s) = %q, want error", in, got)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuoteJava returns s as a double-quoted Java string literal. Control and
+// other non-printable characters are escaped, as \n or \uXXXX, with
+// supplementary characters written as a surrogate pair. Invalid UTF-8 is
+// written as U+FFFD.
+func QuoteJava(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		i += n
+		if r == utf8.RuneError && n == 1 {
+			sb.WriteRune(utf8.RuneError)
+			continue
+		}
+
+		switch r {
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				sb.WriteRune(r)
+			case r > 0xffff:
+				hi, lo := EncodeSurrogatePair(r)
+				writeCharEscape(&sb, rune(hi))
+				writeCharEscape(&sb, rune(lo))
+			default:
+				writeCharEscape(&sb, r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// writeCharEscape writes the \uXXXX escape for the char c.
+func writeCharEscape(sb *strings.Builder, c rune) {
+	const digits = "0123456789abcdef"
+	sb.WriteString(`\u`)
+	for shift := 12; shift >= 0; shift -= 4 {
+		sb.WriteByte(digits[c>>shift&0xf])
+	}
+}
+
+// UnquoteJava interprets s as a double-quoted Java string literal and
+// returns the string it stands for. It accepts the escapes of the Java
+// language: \b, \t, \n, \f, \r, \s, \", \', \\, octal escapes up to \377 and
+// \uXXXX, with any number of u's. A surrogate pair written as two \u escapes
+// is combined into one rune; an unpaired surrogate cannot be represented in
+// a Go string, so it is an error. Errors are strconv.ErrSyntax.
+func UnquoteJava(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", strconv.ErrSyntax
+	}
+	s = s[1 : len(s)-1]
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	var hi uint16 // high surrogate waiting for its other half
+	for len(s) > 0 {
+		c, rest, err := unquoteChar(s)
+		if err != nil {
+			return "", err
+		}
+		s = rest
+
+		// raw supplementary characters are not surrogates
+		u := uint16(c)
+		if c >= surrSelf {
+			u = 0
+		}
+
+		switch {
+		case hi != 0 && IsLowSurrogate(u):
+			sb.WriteRune(DecodeSurrogatePair(hi, u))
+			hi = 0
+		case hi != 0:
+			return "", strconv.ErrSyntax
+		case IsHighSurrogate(u):
+			hi = u
+		case IsLowSurrogate(u):
+			return "", strconv.ErrSyntax
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	if hi != 0 {
+		return "", strconv.ErrSyntax
+	}
+	return sb.String(), nil
+}
+
+// unquoteChar decodes the character or escape at the start of s, which is
+// the inside of a string literal. Surrogates are returned as is.
+func unquoteChar(s string) (rune, string, error) {
+	switch c := s[0]; {
+	case c == '"' || c == '\n' || c == '\r':
+		return 0, "", strconv.ErrSyntax
+	case c != '\\':
+		r, n := utf8.DecodeRuneInString(s)
+		return r, s[n:], nil
+	case len(s) < 2:
+		return 0, "", strconv.ErrSyntax
+	}
+
+	c := s[1]
+	s = s[2:]
+	switch c {
+	case 'b':
+		return '\b', s, nil
+	case 't':
+		return '\t', s, nil
+	case 'n':
+		return '\n', s, nil
+	case 'f':
+		return '\f', s, nil
+	case 'r':
+		return '\r', s, nil
+	case 's':
+		return ' ', s, nil
+	case '"', '\'', '\\':
+		return rune(c), s, nil
+	case 'u':
+		s = strings.TrimLeft(s, "u")
+		if len(s) < 4 {
+			return 0, "", strconv.ErrSyntax
+		}
+		v, err := strconv.ParseUint(s[:4], 16, 16)
+		if err != nil {
+			return 0, "", strconv.ErrSyntax
+		}
+		return rune(v), s[4:], nil
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		// up to three digits for \0 to \377, two for \40 to \77
+		v := rune(c - '0')
+		n := 2
+		if c <= '3' {
+			n = 3
+		}
+		for i := 1; i < n && len(s) > 0 && s[0] >= '0' && s[0] <= '7'; i++ {
+			v = v*8 + rune(s[0]-'0')
+			s = s[1:]
+		}
+		return v, s, nil
+	}
+	return 0, "", strconv.ErrSyntax
+}
@@ -0,0 +1,61 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "testing"
+
+func TestQuoteJava(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", `""`},
+		{"hello", `"hello"`},
+		{"a\"b\\c", `"a\"b\\c"`},
+		{"\b\t\n\f\r", `"\b\t\n\f\r"`},
+		{"a\x00\x1f\x7f", `"a\u0000\u001f\u007f"`},
+		{"åäö 日本語 💩", `"åäö 日本語 💩"`},
+		{"\u200b\U000e0001", `"\u200b\udb40\udc01"`},
+		{"bad \xff", `"bad �"`},
+	}
+	for _, tt := range tests {
+		if got := QuoteJava(tt.in); got != tt.want {
+			t.Errorf("QuoteJava(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if tt.in == "bad \xff" {
+			continue
+		}
+		if got, err := UnquoteJava(QuoteJava(tt.in)); got != tt.in || err != nil {
+			t.Errorf("UnquoteJava(%s) = %q, %v; want %q", QuoteJava(tt.in), got, err, tt.in)
+		}
+	}
+}
+
+func TestUnquoteJava(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`""`, ""},
+		{`"it's"`, "it's"},
+		{`"\'\"\\\s"`, "'\"\\ "},
+		{`"A\uuu0042"`, "AB"},
+		{`"\ud83d\udca9"`, "\U0001f4a9"},
+		{`"\0\12\101\377\400"`, "\x00\nAÿ 0"},
+		{`"\7\78"`, "\a\a8"},
+		{`"\uD83D\uDCA9"`, "\U0001f4a9"},
+	}
+	for _, tt := range tests {
+		if got, err := UnquoteJava(tt.in); got != tt.want || err != nil {
+			t.Errorf("UnquoteJava(%s) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		``, `"`, `abc`, `"a"b"`, "\"a\nb\"", `"\"`, `"\x41"`, `"\u41"`, `"\uzzzz"`,
+		`"\ud83d"`, `"\udca9"`, `"\ud83dx"`, `"\ud83d\ud83d"`, "\"\\ud83d\U0001dc00\"",
+	} {
+		if got, err := UnquoteJava(in); err == nil {
+			t.Errorf("UnquoteJava(%s) = %q, want error", in, got)
+		}
+	}
+}
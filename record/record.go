@@ -0,0 +1,219 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+// Package record reads and writes binary records of the kind written field
+// by field with DataOutput, such as the headers of Java file formats, into
+// and from tagged Go structs.
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/anders/jutf"
+)
+
+var errNotStruct = errors.New("not a pointer to a struct")
+
+// Read fills the struct pointed to by v from a big-endian binary
+// record, as written by DataOutput, field by field in order. Fields of
+// string or Data type are read as a 16-bit length followed by that many
+// bytes, and must be tagged with how to decode them:
+//
+//	`jutf:"utf"` decodes as jutf.ReadUTF, failing with a *jutf.JavaError as
+//	             Java does
+//	`jutf:"nbt"` decodes leniently, as for NBT, where writers may use either
+//	             form and malformed sequences become U+FFFD
+//
+// Data fields are not decoded. Nested structs are read recursively, other
+// fields as by binary.Read, and fields tagged `jutf:"-"` are skipped. Errors
+// reading a field are wrapped in an *Error.
+func Read(r io.Reader, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errNotStruct
+	}
+	return readStruct(r, rv.Elem())
+}
+
+func readStruct(r io.Reader, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("jutf")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+
+		if err := readField(r, v.Field(i), tag); err != nil {
+			return structError(t, f, err)
+		}
+	}
+	return nil
+}
+
+func readField(r io.Reader, v reflect.Value, tag string) error {
+	if !isString(v.Type()) {
+		if tag != "" {
+			return errBadTag
+		}
+		if v.Kind() == reflect.Struct {
+			return readStruct(r, v)
+		}
+		return binary.Read(r, binary.BigEndian, v.Addr().Interface())
+	}
+
+	if tag != "utf" && tag != "nbt" {
+		return errBadTag
+	}
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return eofError(err)
+	}
+	d := make([]byte, binary.BigEndian.Uint16(hdr[:]))
+	if _, err := io.ReadFull(r, d); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return eofError(err)
+	}
+
+	if v.Kind() == reflect.Slice {
+		v.SetBytes(d)
+		return nil
+	}
+
+	var s string
+	var err error
+	if tag == "utf" {
+		s, err = jutf.DecodeJava(d)
+	} else {
+		// a Decoder accepts the standard forms anywhere
+		s, err = jutf.DecodeReader(bytes.NewReader(d), jutf.Lossy())
+	}
+	v.SetString(s)
+	return err
+}
+
+// eofError converts the EOF errors from io.ReadFull to the *jutf.JavaError
+// that ReadUTF returns for them.
+func eofError(err error) error {
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return &jutf.JavaError{Exception: jutf.EOFException, Offset: -1, Err: err}
+}
+
+// Write writes the struct v, or the struct it points to, as a big-endian
+// binary record in the layout read by Read. Strings are encoded as by
+// jutf.WriteUTF for both tags, and like Java it fails with a
+// *jutf.JavaError, wrapped in an *Error, if one encodes to more than 65535
+// bytes. The record is written to w at once.
+func Write(w io.Writer, v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return errNotStruct
+	}
+
+	b, err := appendStruct(nil, rv)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func appendStruct(b []byte, v reflect.Value) ([]byte, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("jutf")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+
+		var err error
+		if b, err = appendField(b, v.Field(i), tag); err != nil {
+			return nil, structError(t, f, err)
+		}
+	}
+	return b, nil
+}
+
+func appendField(b []byte, v reflect.Value, tag string) ([]byte, error) {
+	if !isString(v.Type()) {
+		if tag != "" {
+			return nil, errBadTag
+		}
+		if v.Kind() == reflect.Struct {
+			return appendStruct(b, v)
+		}
+		return binary.Append(b, binary.BigEndian, v.Interface())
+	}
+
+	if tag != "utf" && tag != "nbt" {
+		return nil, errBadTag
+	}
+
+	var n int
+	if v.Kind() == reflect.Slice {
+		n = v.Len()
+	} else {
+		n = jutf.EncodedLen(v.String())
+	}
+	if n > maxUTF {
+		return nil, &jutf.JavaError{
+			Exception: jutf.UTFDataFormatException,
+			Msg:       "encoded string too long: " + strconv.Itoa(n) + " bytes",
+			Offset:    -1,
+		}
+	}
+
+	b = binary.BigEndian.AppendUint16(b, uint16(n))
+	if v.Kind() == reflect.Slice {
+		return append(b, v.Bytes()...), nil
+	}
+	return jutf.AppendEncode(b, v.String()), nil
+}
+
+// maxUTF is the most data DataOutput.writeUTF can write after the length.
+const maxUTF = 0xffff
+
+var dataType = reflect.TypeFor[jutf.Data]()
+
+// isString reports whether fields of type t hold a framed string.
+func isString(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t == dataType
+}
+
+var errBadTag = errors.New(`strings need a "utf" or "nbt" tag, and only strings take one`)
+
+// An Error reports the field of a struct that Read or Write failed on.
+type Error struct {
+	Struct string // type name of the struct
+	Field  string
+	Err    error
+}
+
+func structError(t reflect.Type, f reflect.StructField, err error) error {
+	var se *Error
+	if errors.As(err, &se) {
+		// from a nested struct
+		se.Field = f.Name + "." + se.Field
+		se.Struct = t.String()
+		return se
+	}
+	return &Error{Struct: t.String(), Field: f.Name, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Struct + "." + e.Field + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,110 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package record
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anders/jutf"
+)
+
+type testHeader struct {
+	Magic   uint32
+	Version [2]uint16
+}
+
+type testRecord struct {
+	Header  testHeader
+	Name    string `jutf:"utf"`
+	Flags   uint8
+	Comment string    `jutf:"nbt"`
+	Raw     jutf.Data `jutf:"utf"`
+	Cached  string    `jutf:"-"`
+	private int
+}
+
+func TestRecord(t *testing.T) {
+	want := testRecord{
+		Header:  testHeader{0xcafebabe, [2]uint16{0, 52}},
+		Name:    "a\x00\U0001f4a9",
+		Flags:   7,
+		Comment: "b",
+		Raw:     jutf.Data{0xc0, 0x80},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, &want); err != nil {
+		t.Fatal(err)
+	}
+	wantBytes := []byte{
+		0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52,
+		0, 9, 'a', 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9,
+		7,
+		0, 1, 'b',
+		0, 2, 0xc0, 0x80,
+	}
+	if !bytes.Equal(buf.Bytes(), wantBytes) {
+		t.Errorf("Write() wrote %x, want %x", buf.Bytes(), wantBytes)
+	}
+
+	got := testRecord{Cached: "kept"}
+	if err := Read(&buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	want.Cached = "kept"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadNBT(t *testing.T) {
+	var rec struct {
+		S string `jutf:"nbt"`
+	}
+	// standard UTF-8 and malformed input are accepted
+	data := []byte{0, 10, 0xf0, 0x9f, 0x92, 0xa9, 0, 0xc0, 0x80, 0xff, 0xed, 0xa0}
+	if err := Read(bytes.NewReader(data), &rec); err != nil || rec.S != "\U0001f4a9\x00\x00\ufffd\ufffd" {
+		t.Errorf("Read() = %q, %v", rec.S, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var rec testRecord
+	var se *Error
+	var je *jutf.JavaError
+
+	err := Read(bytes.NewReader([]byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 52, 0, 1, 0xff}), &rec)
+	if !errors.As(err, &se) || se.Field != "Name" || !errors.As(err, &je) || je.Exception != jutf.UTFDataFormatException {
+		t.Errorf("Read(malformed) error = %v", err)
+	}
+
+	err = Read(bytes.NewReader([]byte{0xca, 0xfe}), &rec)
+	if !errors.As(err, &se) || se.Field != "Header.Magic" || se.Struct != "record.testRecord" || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Read(short) error = %v", err)
+	}
+
+	rec.Name = strings.Repeat("\x00", 40000)
+	if err := Write(io.Discard, rec); !errors.As(err, &je) || !errors.As(err, &se) || se.Field != "Name" {
+		t.Errorf("Write(too long) error = %v", err)
+	}
+
+	var untagged struct{ S string }
+	if err := Read(bytes.NewReader(nil), &untagged); !errors.Is(err, errBadTag) {
+		t.Errorf("Read(untagged) error = %v", err)
+	}
+	if err := Write(io.Discard, untagged); !errors.Is(err, errBadTag) {
+		t.Errorf("Write(untagged) error = %v", err)
+	}
+	if err := Read(bytes.NewReader(nil), rec); err != errNotStruct {
+		t.Errorf("Read(non-pointer) error = %v", err)
+	}
+	if err := Write(io.Discard, 1); err != errNotStruct {
+		t.Errorf("Write(int) error = %v", err)
+	}
+}
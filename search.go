@@ -0,0 +1,280 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HasPrefix reports whether b begins with the encoding of prefix. NULs and
+// supplementary characters match in both the modified and standard forms.
+func HasPrefix(b []byte, prefix string) bool {
+	_, j, _, _ := match(b, prefix)
+	return j == len(prefix)
+}
+
+// HasSuffix reports whether b ends with the encoding of suffix. NULs and
+// supplementary characters match in both the modified and standard forms.
+func HasSuffix(b []byte, suffix string) bool {
+	i, j := len(b), len(suffix)
+	for j > 0 {
+		start, r := lastRune(b[:i])
+		if start < 0 {
+			return false
+		}
+		sr, size := utf8.DecodeLastRuneInString(suffix[:j])
+		if sr != r || size == 1 && sr == utf8.RuneError {
+			return false
+		}
+		i, j = start, j-size
+	}
+	return true
+}
+
+// Contains reports whether b contains the encoding of substr. NULs and
+// supplementary characters match in both the modified and standard forms.
+func Contains(b []byte, substr string) bool {
+	return IndexString(b, substr) >= 0
+}
+
+// IndexString returns the byte offset of the first encoding of substr in b,
+// or -1 if there is none, without decoding b. NULs and supplementary
+// characters match in both the modified and standard forms.
+func IndexString(b []byte, substr string) int {
+	i, _ := indexString(b, substr)
+	return i
+}
+
+// indexString is IndexString, also returning the length of the match, which
+// depends on the forms used in b.
+func indexString(b []byte, substr string) (int, int) {
+	if !NeedsEncoding(substr) {
+		// the same in either form
+		return bytes.Index(b, stringBytes(substr)), len(substr)
+	}
+
+	// the part before the first NUL or supplementary character is the same
+	// in either form, so look for that first
+	head := 0
+	for head < len(substr) && substr[head] != 0 && substr[head] < 0xf0 {
+		head++
+	}
+
+	for i := 0; i < len(b); i++ {
+		var k int
+		if head > 0 {
+			k = bytes.Index(b[i:], stringBytes(substr[:head]))
+		} else {
+			r, _ := utf8.DecodeRuneInString(substr)
+			k = IndexRune(b[i:], r)
+		}
+		if k < 0 {
+			break
+		}
+		i += k
+		if n, j, _, _ := match(b[i:], substr); j == len(substr) {
+			return i, n
+		}
+	}
+	return -1, 0
+}
+
+// IndexRune returns the byte offset of the first sequence in b decoding to
+// r, or -1 if there is none. NULs and supplementary characters are found in
+// both the modified and standard forms. If r is utf8.RuneError, it returns
+// the first malformed sequence or U+FFFD.
+func IndexRune(b []byte, r rune) int {
+	switch {
+	case r > 0 && r < utf8.RuneSelf:
+		return bytes.IndexByte(b, byte(r))
+	case r == utf8.RuneError:
+		return indexInvalid(b, false)
+	case !utf8.ValidRune(r):
+		return -1
+	}
+
+	var buf [10]byte
+	mod, std := runeForms(&buf, r)
+	i := bytes.Index(b, mod)
+	if j := bytes.Index(b, std); j >= 0 && (i < 0 || j < i) {
+		i = j
+	}
+	return i
+}
+
+// LastIndexRune returns the byte offset of the last sequence in b decoding
+// to r, or -1 if there is none, matching the same sequences as IndexRune.
+func LastIndexRune(b []byte, r rune) int {
+	switch {
+	case r > 0 && r < utf8.RuneSelf:
+		return bytes.LastIndexByte(b, byte(r))
+	case r == utf8.RuneError:
+		return indexInvalid(b, true)
+	case !utf8.ValidRune(r):
+		return -1
+	}
+
+	var buf [10]byte
+	mod, std := runeForms(&buf, r)
+	i := bytes.LastIndex(b, mod)
+	if j := bytes.LastIndex(b, std); j > i {
+		i = j
+	}
+	return i
+}
+
+// runeForms returns the modified and standard encodings of r, stored in buf,
+// which are the same unless r is NUL or a supplementary character.
+func runeForms(buf *[10]byte, r rune) (mod, std []byte) {
+	std = buf[:utf8.EncodeRune(buf[:], r)]
+	mod = appendRune(buf[len(std):len(std)], r)
+	return mod, std
+}
+
+// indexInvalid returns the offset of the first, or last, sequence in b that
+// is malformed or U+FFFD.
+func indexInvalid(b []byte, last bool) int {
+	at := -1
+	for i := 0; i < len(b); {
+		i += asciiSpan(b[i:])
+		if i == len(b) {
+			break
+		}
+
+		n, err := scan(b[i:])
+		if err != nil && err != ErrInvalidNUL && err != ErrFourByte || string(b[i:i+n]) == "\ufffd" {
+			if at = i; !last {
+				break
+			}
+		}
+		i += n
+	}
+	return at
+}
+
+// Cut slices b around the first encoding of sep, returning the parts before
+// and after it, as bytes.Cut. If sep is not found, it returns b, nil, false.
+func Cut(b []byte, sep string) (before, after []byte, found bool) {
+	if i, n := indexString(b, sep); i >= 0 {
+		return b[:i], b[i+n:], true
+	}
+	return b, nil, false
+}
+
+// Split slices b into the parts separated by encodings of sep, as
+// bytes.Split. An empty sep splits after each sequence. The parts share
+// memory with b.
+func Split(b []byte, sep string) [][]byte {
+	if sep == "" {
+		parts := make([][]byte, 0, len(b))
+		for i := 0; i < len(b); {
+			_, n := DecodeRune(b[i:])
+			parts = append(parts, b[i:i+n:i+n])
+			i += n
+		}
+		return parts
+	}
+
+	var parts [][]byte
+	for {
+		i, n := indexString(b, sep)
+		if i < 0 {
+			return append(parts, b)
+		}
+		parts = append(parts, b[:i:i])
+		b = b[i+n:]
+	}
+}
+
+// Fields slices b around runs of white space, as defined by unicode.IsSpace,
+// as bytes.Fields. The fields share memory with b.
+func Fields(b []byte) [][]byte {
+	var fields [][]byte
+	start := -1
+	for i := 0; i < len(b); {
+		r, n := DecodeRune(b[i:])
+		if space := unicode.IsSpace(r); space && start >= 0 {
+			fields = append(fields, b[start:i:i])
+			start = -1
+		} else if !space && start < 0 {
+			start = i
+		}
+		i += n
+	}
+	if start >= 0 {
+		fields = append(fields, b[start:])
+	}
+	return fields
+}
+
+// DecodeRune decodes the first sequence of b, returning the rune and its
+// length in bytes, like utf8.DecodeRune. Malformed sequences give U+FFFD,
+// with the length of the problem, and empty input gives (U+FFFD, 0). Raw
+// NULs and 4-byte sequences are decoded as well.
+func DecodeRune(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return utf8.RuneError, 0
+	}
+	if b[0] < utf8.RuneSelf {
+		return rune(b[0]), 1
+	}
+
+	n, err := scan(b)
+	switch {
+	case err == nil && b[0] == 0xc0:
+		return 0, 2
+	case err == nil && n == 6:
+		return decodePair(b), 6
+	case err == nil || err == ErrFourByte:
+		r, _ := utf8.DecodeRune(b[:n])
+		return r, n
+	}
+	return utf8.RuneError, n
+}
+
+// DecodeLastRune decodes the last sequence of b, like utf8.DecodeLastRune.
+// Malformed input gives (U+FFFD, 1), and empty input (U+FFFD, 0).
+func DecodeLastRune(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return utf8.RuneError, 0
+	}
+	if start, r := lastRune(b); start >= 0 {
+		return r, len(b) - start
+	}
+	return utf8.RuneError, 1
+}
+
+// lastRune decodes the last sequence of d, returning where it starts, or -1
+// if it is malformed or d is empty.
+func lastRune(d []byte) (start int, r rune) {
+	if len(d) == 0 {
+		return -1, 0
+	}
+	if c := d[len(d)-1]; c < 0x80 {
+		return len(d) - 1, rune(c)
+	}
+
+	k := len(d) - 1
+	for k > 0 && k > len(d)-utf8.UTFMax && d[k]&0xc0 == 0x80 {
+		k--
+	}
+
+	n, err := scan(d[k:])
+	switch {
+	case n != len(d)-k:
+	case err == nil && d[k] == 0xc0:
+		return k, 0
+	case err == nil || err == ErrFourByte:
+		r, _ := utf8.DecodeRune(d[k:])
+		return k, r
+	case err == ErrUnpairedSurrogate && k >= 3:
+		// the second half of a pair
+		if n, err := scan(d[k-3:]); err == nil && n == 6 {
+			return k - 3, decodePair(d[k-3:])
+		}
+	}
+	return -1, 0
+}
@@ -0,0 +1,229 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasPrefixSuffix(t *testing.T) {
+	tests := []struct {
+		b              []byte
+		s              string
+		prefix, suffix bool
+	}{
+		{nil, "", true, true},
+		{[]byte("java/lang/Object"), "java/lang/", true, false},
+		{[]byte("java/lang/Object"), "Object", false, true},
+		{[]byte("java/lang/Object"), "java/lang/Object", true, true},
+		{[]byte("java"), "java/", false, false},
+		{Encode("a\x00b\U0001f4a9"), "a\x00", true, false},
+		{Encode("a\x00b\U0001f4a9"), "b\U0001f4a9", false, true},
+		{Encode("\U0001f4a9"), "\U0001f4a9", true, true},
+		{Encode("\U0001f4a9"), "\U0001f4aa", false, false},
+		{[]byte("a\x00b\U0001f4a9"), "a\x00b\U0001f4a9", true, true},
+		{[]byte{0, 0xc0, 0x80}, "\x00\x00", true, true},
+		{Encode("é"), "\xc3", false, false},
+		{[]byte{'a', 0xff, 'b'}, "a", true, false},
+		{[]byte{'a', 0xff, 'b'}, "b", false, true},
+		{[]byte{'a', 0xff, 'b'}, "\xffb", false, false},
+		{[]byte{0xed, 0xb2, 0xa9}, "\xed\xb2\xa9", false, false},
+	}
+	for _, tt := range tests {
+		if got := HasPrefix(tt.b, tt.s); got != tt.prefix {
+			t.Errorf("HasPrefix(%x, %q) = %v, want %v", tt.b, tt.s, got, tt.prefix)
+		}
+		if got := HasSuffix(tt.b, tt.s); got != tt.suffix {
+			t.Errorf("HasSuffix(%x, %q) = %v, want %v", tt.b, tt.s, got, tt.suffix)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		s    string
+		want bool
+	}{
+		{nil, "", true},
+		{nil, "a", false},
+		{[]byte("java/lang/Object"), "lang", true},
+		{[]byte("java/lang/Object"), "long", false},
+		{Encode("a\x00b\U0001f4a9c"), "\x00b\U0001f4a9", true},
+		{append([]byte("ab\x00"), Encode("\U0001f4a9c")...), "b\x00\U0001f4a9", true},
+		{Encode("\U0001f4a9"), "\xed\xb2\xa9", false},
+		{[]byte{0xff, 'a', 0xff}, "a", true},
+	}
+	for _, tt := range tests {
+		if got := Contains(tt.b, tt.s); got != tt.want {
+			t.Errorf("Contains(%x, %q) = %v, want %v", tt.b, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRune(t *testing.T) {
+	tests := []struct {
+		b           []byte
+		r           rune
+		first, last int
+	}{
+		{nil, 'a', -1, -1},
+		{[]byte("Ljava/lang/Object;"), '/', 5, 10},
+		{[]byte("Ljava/lang/Object;"), ';', 17, 17},
+		{Encode("a\x00b\x00"), 0, 1, 4},
+		{[]byte{'a', 0, 0xc0, 0x80}, 0, 1, 2},
+		{Encode("é\U0001f4a9x\U0001f4a9"), 0x1f4a9, 2, 9},
+		{append([]byte("\U0001f4a9"), Encode("\U0001f4a9")...), 0x1f4a9, 0, 4},
+		{Encode("\U0001f4a9"), 0xdcb2, -1, -1},
+		{Encode("\U0001f4a9"), 0xfffd, -1, -1},
+		{[]byte{'a', 0xff, 0xef, 0xbf, 0xbd, 0xe6}, 0xfffd, 1, 5},
+		{[]byte("abc"), 0x110000, -1, -1},
+	}
+	for _, tt := range tests {
+		if got := IndexRune(tt.b, tt.r); got != tt.first {
+			t.Errorf("IndexRune(%x, %U) = %d, want %d", tt.b, tt.r, got, tt.first)
+		}
+		if got := LastIndexRune(tt.b, tt.r); got != tt.last {
+			t.Errorf("LastIndexRune(%x, %U) = %d, want %d", tt.b, tt.r, got, tt.last)
+		}
+	}
+}
+
+func TestIndexString(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		s    string
+		want int
+	}{
+		{nil, "", 0},
+		{[]byte("abc"), "", 0},
+		{nil, "a", -1},
+		{[]byte("java/lang/Object"), "lang", 5},
+		{[]byte("java/lang/Object"), "long", -1},
+		{Encode("ab\x00c\x00d"), "\x00d", 5},
+		{[]byte("ab\x00c\x00d"), "\x00d", 4},
+		{append([]byte("\x00x\x00"), Encode("\U0001f4a9")...), "\x00\U0001f4a9", 2},
+		{Encode("x\U0001f4a9"), "\U0001f4a9", 1},
+		{Encode("x\U0001f4a9"), "\xff", -1},
+		{append(Encode("\U0001f4a9a\x00"), "b\x00"...), "\x00b\x00", 7},
+		{append(Encode("b\x00"), "b\x00"...), "b\x00", 0},
+	}
+	for _, tt := range tests {
+		if got := IndexString(tt.b, tt.s); got != tt.want {
+			t.Errorf("IndexString(%x, %q) = %d, want %d", tt.b, tt.s, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkIndexString(b *testing.B) {
+	entries := make([][]byte, 0, 100000)
+	for i := 0; i < cap(entries); i++ {
+		entries = append(entries, Encode("Lcom/example/app/Class$Inner\x00Generated;"))
+	}
+
+	for _, needle := range []string{"Inner", "Inner\x00Gen"} {
+		b.Run("IndexString/"+needle, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, e := range entries {
+					IndexString(e, needle)
+				}
+			}
+		})
+		b.Run("Decode/"+needle, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, e := range entries {
+					s, _ := Decode(e)
+					strings.Index(s, needle)
+				}
+			}
+		})
+	}
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		b             []byte
+		sep           string
+		before, after string
+		found         bool
+	}{
+		{[]byte("java/lang/Object"), "/", "java", "lang/Object", true},
+		{[]byte("java/lang/Object"), "::", "java/lang/Object", "", false},
+		{Encode("key\x00value"), "\x00", "key", "value", true},
+		{[]byte("key\x00value"), "\x00", "key", "value", true},
+		{Encode("a\U0001f4a9b"), "\U0001f4a9", "a", "b", true},
+	}
+	for _, tt := range tests {
+		before, after, found := Cut(tt.b, tt.sep)
+		if string(before) != tt.before || string(after) != tt.after || found != tt.found {
+			t.Errorf("Cut(%q, %q) = %q, %q, %v", tt.b, tt.sep, before, after, found)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		sep  string
+		want []string
+	}{
+		{nil, "/", []string{""}},
+		{[]byte("java/lang/Object"), "/", []string{"java", "lang", "Object"}},
+		{[]byte("/a//"), "/", []string{"", "a", "", ""}},
+		{Encode("a\x00b\x00c"), "\x00", []string{"a", "b", "c"}},
+		{Encode("a\x00é\U0001f4a9"), "", []string{"a", "\xc0\x80", "é", string(Encode("\U0001f4a9"))}},
+	}
+	for _, tt := range tests {
+		got := Split(tt.b, tt.sep)
+		if len(got) != len(tt.want) {
+			t.Errorf("Split(%q, %q) = %q, want %q", tt.b, tt.sep, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if string(got[i]) != tt.want[i] {
+				t.Errorf("Split(%q, %q) = %q, want %q", tt.b, tt.sep, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	b := Encode("  public\tstatic void\x00 main\u3000")
+	want := []string{"public", "static", string(Encode("void\x00")), "main"}
+	got := Fields(b)
+	if len(got) != len(want) {
+		t.Fatalf("Fields() = %q, want %q", got, want)
+	}
+	for i := range got {
+		if string(got[i]) != want[i] {
+			t.Errorf("Fields()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDecodeRune(t *testing.T) {
+	tests := []struct {
+		b           []byte
+		first, last rune
+		fn, ln      int
+	}{
+		{nil, 0xfffd, 0xfffd, 0, 0},
+		{[]byte("ab"), 'a', 'b', 1, 1},
+		{Encode("\x00"), 0, 0, 2, 2},
+		{Encode("é\U0001f4a9"), 'é', 0x1f4a9, 2, 6},
+		{[]byte("\U0001f4a9\x00"), 0x1f4a9, 0, 4, 1},
+		{[]byte{0xed, 0xb2, 0xa9}, 0xfffd, 0xfffd, 3, 1},
+		{[]byte{0xe6, 0x97}, 0xfffd, 0xfffd, 2, 1},
+	}
+	for _, tt := range tests {
+		if r, n := DecodeRune(tt.b); r != tt.first || n != tt.fn {
+			t.Errorf("DecodeRune(%x) = %U, %d; want %U, %d", tt.b, r, n, tt.first, tt.fn)
+		}
+		if r, n := DecodeLastRune(tt.b); r != tt.last || n != tt.ln {
+			t.Errorf("DecodeLastRune(%x) = %U, %d; want %U, %d", tt.b, r, n, tt.last, tt.ln)
+		}
+	}
+}
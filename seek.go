@@ -0,0 +1,213 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+var (
+	errWhence       = errors.New("invalid whence")
+	errNegativeSeek = errors.New("negative position")
+)
+
+// A SeekDecoder is a Decoder over a seekable source, which can itself seek in
+// the decoded content. Offsets are translated between the encoded source and
+// the decoded output by scanning forward from the nearest offset pair already
+// seen; pairs are kept every 4 KB of input, so that random access into a
+// large source only scans it once.
+type SeekDecoder struct {
+	r     io.ReadSeeker
+	o     options
+	marks []mark // ascending, starting with the zero mark
+
+	d    *Decoder // positioned at pos, or nil
+	pos  int64    // decoded offset
+	skip int64    // bytes to discard from d to reach pos
+
+	buf []byte
+}
+
+// A mark is a pair of matching offsets at the start of a sequence.
+type mark struct {
+	enc, dec int64
+}
+
+// NewSeekDecoder returns a SeekDecoder reading from r, which must be at its
+// start. The options are the same as for NewDecoder.
+func NewSeekDecoder(r io.ReadSeeker, opts ...Option) *SeekDecoder {
+	s := &SeekDecoder{r: r, o: newOptions(opts), marks: []mark{{}}}
+	s.o.std = !s.o.strict
+	return s
+}
+
+// Read reads decoded UTF-8 into p, as Decoder.Read.
+func (s *SeekDecoder) Read(p []byte) (int, error) {
+	if s.d == nil {
+		m, err := s.locate(s.pos, true)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.r.Seek(m.enc, io.SeekStart); err != nil {
+			return 0, err
+		}
+		s.d = &Decoder{r: s.r, o: s.o, inOff: m.enc, outLen: m.dec}
+		s.skip = s.pos - m.dec
+	}
+
+	// pos may be in the middle of a rune, or past the end
+	for s.skip > 0 {
+		var tmp [utf8.UTFMax]byte
+		n, err := s.d.Read(tmp[:min64(s.skip, len(tmp))])
+		if s.skip -= int64(n); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.d.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// Seek sets the offset in the decoded output for the next Read, as
+// io.Seeker. Seeking relative to the end scans all of the source, unless
+// that has been done before.
+func (s *SeekDecoder) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		end, err := s.locate(math.MaxInt64, true)
+		if err != nil {
+			return 0, err
+		}
+		offset += end.dec
+	default:
+		return 0, errWhence
+	}
+	if offset < 0 {
+		return 0, errNegativeSeek
+	}
+
+	if offset != s.pos {
+		s.pos = offset
+		s.d = nil
+	}
+	return offset, nil
+}
+
+// DecodedOffset returns the offset in the decoded output of the sequence
+// containing the byte at offset enc of the source. Offsets past the end are
+// mapped to the end.
+func (s *SeekDecoder) DecodedOffset(enc int64) (int64, error) {
+	m, err := s.locate(enc, false)
+	return m.dec, err
+}
+
+// EncodedOffset returns the offset in the source of the sequence decoding to
+// the byte at offset dec of the output. Offsets past the end are mapped to
+// the end.
+func (s *SeekDecoder) EncodedOffset(dec int64) (int64, error) {
+	m, err := s.locate(dec, true)
+	return m.enc, err
+}
+
+// locate returns the mark at the start of the sequence containing target,
+// which is a decoded offset if decoded is set, and an encoded one otherwise.
+// It moves the source, so the Decoder is reset.
+func (s *SeekDecoder) locate(target int64, decoded bool) (mark, error) {
+	key := func(m mark) int64 {
+		if decoded {
+			return m.dec
+		}
+		return m.enc
+	}
+
+	k := sort.Search(len(s.marks), func(k int) bool { return key(s.marks[k]) > target })
+	m := s.marks[k-1]
+
+	s.d = nil
+	if _, err := s.r.Seek(m.enc, io.SeekStart); err != nil {
+		return mark{}, err
+	}
+
+	if s.buf == nil {
+		s.buf = make([]byte, bufSize)
+	}
+	buf := s.buf[:0]
+	i := 0
+	eof := false
+	for {
+		// keep a whole sequence buffered, or all that is left
+		if len(buf)-i < 2*utf8.UTFMax && !eof {
+			n := copy(s.buf, buf[i:])
+			r, err := s.r.Read(s.buf[n:])
+			buf, i = s.buf[:n+r], 0
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return mark{}, err
+			}
+			continue
+		}
+		if i == len(buf) {
+			return m, nil
+		}
+
+		// a byte order mark dropped by StripBOM decodes to nothing, so the
+		// offsets inside it map to the start of the output
+		if m.enc == 0 {
+			if n := s.o.bomLen(buf); n > 0 {
+				m.enc += int64(n)
+				i += n
+				if target < key(m) {
+					return m, nil
+				}
+				continue
+			}
+		}
+
+		if last := s.marks[len(s.marks)-1]; m.enc >= last.enc+bufSize {
+			s.marks = append(s.marks, m)
+		}
+
+		// ASCII maps one to one
+		if span := int64(asciiSpan(buf[i:])); span > 0 {
+			if target < key(m)+span {
+				d := target - key(m)
+				return mark{m.enc + d, m.dec + d}, nil
+			}
+			m.enc += span
+			m.dec += span
+			i += int(span)
+			continue
+		}
+
+		var tmp [utf8.UTFMax]byte
+		n, err := scan(buf[i:])
+		out, err := decodeSeq(tmp[:0], buf[i:i+n], err, &s.o)
+		if err != nil {
+			return mark{}, streamError(buf, i, m.enc-int64(i), err)
+		}
+
+		next := mark{m.enc + int64(n), m.dec + int64(len(out))}
+		if target < key(next) {
+			return m, nil
+		}
+		m = next
+		i += n
+	}
+}
+
+func min64(a int64, b int) int {
+	if a < int64(b) {
+		return int(a)
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSeekDecoder(t *testing.T) {
+	text := strings.Repeat("abc\x00\U0001f4a9å", 3000)
+	s := NewSeekDecoder(bytes.NewReader(Encode(text)))
+
+	for _, off := range []int64{0, 5, 20000, 3, 10000, 4, 29999, int64(len(text))} {
+		if pos, err := s.Seek(off, io.SeekStart); pos != off || err != nil {
+			t.Fatalf("Seek(%d) = %d, %v", off, pos, err)
+		}
+		got := make([]byte, 10)
+		n, err := io.ReadFull(s, got)
+		want := text[off:]
+		if len(want) > 10 {
+			want = want[:10]
+		}
+		if string(got[:n]) != want || n < 10 && err == nil {
+			t.Errorf("Read at %d = %q, %v; want %q", off, got[:n], err, want)
+		}
+	}
+
+	if end, err := s.Seek(-3, io.SeekEnd); end != int64(len(text)-3) || err != nil {
+		t.Errorf("Seek(-3, end) = %d, %v", end, err)
+	}
+	if rest, err := io.ReadAll(s); string(rest) != text[len(text)-3:] || err != nil {
+		t.Errorf("ReadAll() = %q, %v", rest, err)
+	}
+	if _, err := s.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Seek(-1) succeeded")
+	}
+	if n, err := s.Seek(1<<20, io.SeekStart); err != nil {
+		t.Errorf("Seek(%d) = %d, %v", 1<<20, n, err)
+	} else if n, err := s.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Read past end = %d, %v", n, err)
+	}
+}
+
+func TestSeekDecoderOffsets(t *testing.T) {
+	// a\0 is 3 bytes encoded, 2 decoded; U+1F4A9 is 6 and 4.
+	s := NewSeekDecoder(bytes.NewReader(Encode("a\x00\U0001f4a9b")))
+	tests := []struct {
+		enc, dec int64
+		toDec    int64 // DecodedOffset(enc)
+		toEnc    int64 // EncodedOffset(dec)
+	}{
+		{0, 0, 0, 0},
+		{1, 1, 1, 1},
+		{2, 2, 1, 3},
+		{3, 3, 2, 3},
+		{5, 5, 2, 3},
+		{9, 6, 6, 9},
+		{10, 7, 7, 10},
+		{99, 99, 7, 10},
+	}
+	for _, tt := range tests {
+		if got, err := s.DecodedOffset(tt.enc); got != tt.toDec || err != nil {
+			t.Errorf("DecodedOffset(%d) = %d, %v; want %d", tt.enc, got, err, tt.toDec)
+		}
+		if got, err := s.EncodedOffset(tt.dec); got != tt.toEnc || err != nil {
+			t.Errorf("EncodedOffset(%d) = %d, %v; want %d", tt.dec, got, err, tt.toEnc)
+		}
+	}
+}
+
+func TestSeekDecoderError(t *testing.T) {
+	data := append(Encode(strings.Repeat("\x00", 5000)), 0xff)
+	s := NewSeekDecoder(bytes.NewReader(data))
+
+	var de *DecodeError
+	if _, err := s.Seek(0, io.SeekEnd); !errors.As(err, &de) || de.Offset != 10000 {
+		t.Errorf("Seek(0, end) = %v, want error at offset 10000", err)
+	}
+	if _, err := s.EncodedOffset(4999); err != nil {
+		t.Errorf("EncodedOffset(4999) = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EscapeSmali returns the encoded string b escaped the way baksmali writes
+// the strings of a dex file, without the quotes: printable ASCII as is,
+// with \, " and ' escaped, \n, \r and \t, and every other char as \uXXXX.
+// Chars are escaped one at a time, so unpaired surrogates are kept. Other
+// malformed sequences are written as \ufffd.
+func EscapeSmali(b []byte) string {
+	const digits = "0123456789abcdef"
+
+	var sb strings.Builder
+	sb.Grow(len(b))
+	r := charReader{d: b}
+	for {
+		c, ok := r.next()
+		if !ok {
+			break
+		}
+
+		switch {
+		case c == '\\' || c == '"' || c == '\'':
+			sb.WriteByte('\\')
+			sb.WriteByte(byte(c))
+		case c >= ' ' && c < 0x7f:
+			sb.WriteByte(byte(c))
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteString(`\u`)
+			for shift := 12; shift >= 0; shift -= 4 {
+				sb.WriteByte(digits[c>>shift&0xf])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// UnescapeSmali is the inverse of EscapeSmali, returning the encoding of a
+// string as written in smali source, without the quotes. It accepts the
+// escapes of the smali assembler: \b, \t, \n, \f, \r, \', \", \\ and
+// \uXXXX. Each \u escape is encoded on its own, so the output for a string
+// printed by EscapeSmali is the same bytes it was printed from, as long as
+// those were canonical. Errors are strconv.ErrSyntax.
+func UnescapeSmali(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for len(s) > 0 {
+		if s[0] != '\\' {
+			r, n := utf8.DecodeRuneInString(s)
+			b = appendRune(b, r)
+			s = s[n:]
+			continue
+		}
+
+		if len(s) < 2 {
+			return nil, strconv.ErrSyntax
+		}
+		c := s[1]
+		s = s[2:]
+		switch c {
+		case 'b':
+			b = append(b, '\b')
+		case 't':
+			b = append(b, '\t')
+		case 'n':
+			b = append(b, '\n')
+		case 'f':
+			b = append(b, '\f')
+		case 'r':
+			b = append(b, '\r')
+		case '\'', '"', '\\':
+			b = append(b, c)
+		case 'u':
+			if len(s) < 4 {
+				return nil, strconv.ErrSyntax
+			}
+			v, err := strconv.ParseUint(s[:4], 16, 16)
+			if err != nil {
+				return nil, strconv.ErrSyntax
+			}
+			b = appendRune(b, rune(v))
+			s = s[4:]
+		default:
+			return nil, strconv.ErrSyntax
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeSmali(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{nil, ""},
+		{[]byte("Hello, world"), "Hello, world"},
+		{[]byte(`it's "q" \`), `it\'s \"q\" \\`},
+		{[]byte("a\n\r\t\b\x7f"), `a\n\r\t\u0008\u007f`},
+		{Encode("a\x00b"), `a\u0000b`},
+		{Encode("åäö 日本語"), `\u00e5\u00e4\u00f6 \u65e5\u672c\u8a9e`},
+		{Encode("\U0001f4a9"), `\ud83d\udca9`},
+		{[]byte{0xed, 0xa0, 0xbd, 'x'}, `\ud83dx`},
+		{[]byte{0xed, 0xb2, 0xa9}, `\udca9`},
+		{[]byte{0xff}, `\ufffd`},
+	}
+	for _, tt := range tests {
+		if got := EscapeSmali(tt.in); got != tt.want {
+			t.Errorf("EscapeSmali(%x) = %s, want %s", tt.in, got, tt.want)
+		}
+		if bytes.Equal(tt.in, []byte{0xff}) {
+			continue
+		}
+		if got, err := UnescapeSmali(tt.want); !bytes.Equal(got, tt.in) || err != nil {
+			t.Errorf("UnescapeSmali(%s) = %x, %v; want %x", tt.want, got, err, tt.in)
+		}
+	}
+}
+
+func TestUnescapeSmali(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{`\b\f`, []byte("\b\f")},
+		{"raw åä\x00", []byte("raw åä\xc0\x80")},
+		{"\U0001f4a9", Encode("\U0001f4a9")},
+		{`\ud83d\udca9`, Encode("\U0001f4a9")},
+	}
+	for _, tt := range tests {
+		if got, err := UnescapeSmali(tt.in); !bytes.Equal(got, tt.want) || err != nil {
+			t.Errorf("UnescapeSmali(%s) = %x, %v; want %x", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{`\`, `\s`, `\0`, `\x41`, `\u41`, `\uzzzz`} {
+		if got, err := UnescapeSmali(in); err == nil {
+			t.Errorf("UnescapeSmali(%s) = %x, want error", in, got)
+		}
+	}
+}
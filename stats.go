@@ -0,0 +1,107 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+// Stats describes encoded data, see Analyze.
+type Stats struct {
+	Bytes     int  // length in bytes
+	Runes     int  // number of decoded runes
+	UTF16     int  // length in UTF-16 code units, i.e. Java chars
+	NULs      int  // number of encoded NULs (C0 80)
+	Pairs     int  // number of surrogate pairs
+	Malformed int  // number of malformed sequences
+	ASCII     bool // whether all bytes are in the range 0x01 to 0x7f
+}
+
+// Analyze collects statistics about b. Malformed sequences are counted as
+// one rune each, as if replaced with U+FFFD by Decode using Lossy.
+func Analyze(b []byte) Stats {
+	st := Stats{Bytes: len(b), ASCII: true}
+
+	for i := 0; i < len(b); {
+		n, err := scan(b[i:])
+		if err == nil && n == 1 {
+			st.Runes++
+			st.UTF16++
+			i++
+			continue
+		}
+
+		st.ASCII = false
+		switch {
+		case err != nil:
+			st.Malformed++
+			st.Runes++
+			st.UTF16++
+		case n == 6:
+			st.Pairs++
+			st.Runes++
+			st.UTF16 += 2
+		default:
+			if b[i] == 0xc0 {
+				st.NULs++
+			}
+			st.Runes++
+			st.UTF16++
+		}
+		i += n
+	}
+
+	return st
+}
+
+// DecodeStats describes the transformation made by DecodeWithStats.
+type DecodeStats struct {
+	NULs      int // number of encoded NULs (C0 80) decoded
+	Pairs     int // number of surrogate pairs decoded
+	Replaced  int // number of malformed sequences replaced or skipped
+	Rewritten int // number of input bytes not copied to the output as is
+}
+
+// Changed reports whether the decoding differs from the input, that is,
+// whether Rewritten is non-zero.
+func (st DecodeStats) Changed() bool {
+	return st.Rewritten > 0
+}
+
+// DecodeWithStats is like Decode, but also reports what was transformed, so
+// that callers can tell whether the input is the same as its decoding and
+// keep just one of them. On error, the stats are zero.
+func DecodeWithStats(d []byte, opts ...Option) (string, DecodeStats, error) {
+	o := newOptions(opts)
+	s, err := decodeString(d, &o)
+	if err != nil {
+		return "", DecodeStats{}, err
+	}
+
+	// a byte order mark dropped by StripBOM is rewritten
+	start := o.bomLen(d)
+	st := DecodeStats{Rewritten: start}
+	if i := same(d, start, len(d), &o); i == len(d) {
+		return s, st, nil
+	}
+
+	// d decoded, so what it contains is known to be accepted by o
+	for i := start; i < len(d); {
+		if i += asciiSpan(d[i:]); i == len(d) {
+			break
+		}
+
+		n, err := scan(d[i:])
+		switch {
+		case err == ErrInvalidNUL && o.rawNUL, err != nil && o.onError == Passthrough:
+		case err != nil:
+			st.Replaced++
+			st.Rewritten += n
+		case n == 6:
+			st.Pairs++
+			st.Rewritten += n
+		case d[i] == 0xc0:
+			st.NULs++
+			st.Rewritten += n
+		}
+		i += n
+	}
+	return s, st, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Stats
+	}{
+		{"empty", []byte{}, Stats{ASCII: true}},
+		{"ASCII", []byte("java/lang/Object"), Stats{Bytes: 16, Runes: 16, UTF16: 16, ASCII: true}},
+		{"text", []byte("åäö 日本語"), Stats{Bytes: 16, Runes: 7, UTF16: 7}},
+		{"modified", Encode("a\x00\U0001f4a9\x00"), Stats{Bytes: 11, Runes: 4, UTF16: 5, NULs: 2, Pairs: 1}},
+		{"malformed", []byte{'a', 0, 0xff, 0xed, 0xb2, 0xa9}, Stats{Bytes: 6, Runes: 4, UTF16: 4, Malformed: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Analyze(tt.data); got != tt.want {
+				t.Errorf("Analyze() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeWithStats(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts []Option
+		want DecodeStats
+	}{
+		{"empty", []byte{}, nil, DecodeStats{}},
+		{"ASCII", []byte("java/lang/Object"), nil, DecodeStats{}},
+		{"standard", []byte("a\x00\U0001f4a9"), nil, DecodeStats{}},
+		{"modified", Encode("a\x00\U0001f4a9\x00"), nil, DecodeStats{NULs: 2, Pairs: 1, Rewritten: 10}},
+		{"lossy", []byte{'a', 0xc0, 0x80, 0xff, 0xed, 0xb2, 0xa9}, []Option{Lossy()}, DecodeStats{NULs: 1, Replaced: 2, Rewritten: 6}},
+		{"raw NUL", []byte{0, 0xc0, 0x80}, []Option{RawNUL()}, DecodeStats{NULs: 1, Rewritten: 2}},
+		{"skip", []byte{'a', 0xff, 0xc0, 0x80}, []Option{ErrorPolicy(Skip)}, DecodeStats{NULs: 1, Replaced: 1, Rewritten: 3}},
+		{"passthrough", []byte{'a', 0xff}, []Option{ErrorPolicy(Passthrough)}, DecodeStats{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, st, err := DecodeWithStats(tt.data, tt.opts...)
+			if want, _ := Decode(tt.data, tt.opts...); s != want || err != nil {
+				t.Errorf("DecodeWithStats() = %q, %v; want %q", s, err, want)
+			}
+			if st != tt.want {
+				t.Errorf("DecodeWithStats() stats = %+v, want %+v", st, tt.want)
+			}
+			if changed := s != string(tt.data); st.Changed() != changed {
+				t.Errorf("Changed() = %v, want %v", st.Changed(), changed)
+			}
+		})
+	}
+
+	if _, st, err := DecodeWithStats([]byte{0xc0, 0x80, 0xff}); !errors.Is(err, ErrInvalidEncoding) || st != (DecodeStats{}) {
+		t.Errorf("DecodeWithStats(invalid) = %+v, %v", st, err)
+	}
+}
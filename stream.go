@@ -0,0 +1,558 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+var errNegativeLen = errors.New("negative length")
+
+// bufSize is the size of the buffers used for streaming.
+const bufSize = 4096
+
+// copySize is the size of the input buffer used by WriteTo and ReadFrom.
+const copySize = 32 << 10
+
+// DecodeTo writes the decoding of b to w, without building it in memory
+// first: runs of b that need no transformation are written as is, the rest
+// through a small buffer. It returns the number of bytes written. As with
+// Decode, malformed input results in a *DecodeError, but the output for the
+// input before it has been written by then.
+func DecodeTo(w io.Writer, b []byte, opts ...Option) (int, error) {
+	o := newOptions(opts)
+	if err := o.checkLimits(b); err != nil {
+		return 0, err
+	}
+
+	// if all of b decodes to itself, standard UTF-8 forms are fine
+	start := o.bomLen(b)
+	i := same(b, start, len(b), &o)
+	written, err := w.Write(b[start:i])
+	if err != nil || i == len(b) {
+		return written, err
+	}
+
+	// otherwise they are errors, so stop at them as well
+	plain := o
+	plain.strict = true
+
+	var scratch [bufSize]byte
+	buf := scratch[:0]
+	flush := func() error {
+		n, err := w.Write(buf)
+		written += n
+		buf = buf[:0]
+		return err
+	}
+
+	for i < len(b) {
+		j := same(b, i, len(b), &plain)
+		if len(buf)+j-i > cap(buf) {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+		if j-i > cap(buf) {
+			n, err := w.Write(b[i:j])
+			if written += n; err != nil {
+				return written, err
+			}
+		} else {
+			buf = append(buf, b[i:j]...)
+		}
+
+		if i = j; i == len(b) {
+			break
+		}
+
+		// a sequence decodes to at most 4 bytes
+		if len(buf)+4 > cap(buf) {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+
+		n, err := scan(b[i:])
+		if buf, err = decodeSeq(buf, b[i:i+n], err, &o); err != nil {
+			if ferr := flush(); ferr != nil {
+				return written, ferr
+			}
+			return written, newDecodeError(b, i, err)
+		}
+		i += n
+	}
+
+	if err := flush(); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// DecodeReader reads r to the end and returns its decoding, as
+// io.ReadAll followed by Decode, but decoding as it reads so that the input
+// is not held in memory as well. Use MaxLen to limit how much is read. As
+// with NewDecoder, raw NULs and 4-byte sequences are accepted unless the
+// Strict option is used.
+func DecodeReader(r io.Reader, opts ...Option) (string, error) {
+	var sb strings.Builder
+	_, err := NewDecoder(r, opts...).WriteTo(&sb)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// DecodeAt decodes the n bytes of r at offset off, such as a string in a
+// class file section, reading only those. Offsets in a *DecodeError are
+// relative to off. If r has fewer than n bytes there, the error is
+// io.ErrUnexpectedEOF.
+func DecodeAt(r io.ReaderAt, off int64, n int, opts ...Option) (string, error) {
+	if n < 0 {
+		return "", errNegativeLen
+	}
+	o := newOptions(opts)
+	if o.maxLen > 0 && n > o.maxLen {
+		// enough to fail checkLimits
+		n = o.maxLen + 1
+	}
+
+	d := make([]byte, n)
+	if m, err := r.ReadAt(d, off); m < n {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	// d is not used after this, so the result may share it
+	o.zeroCopy = true
+	return decodeString(d, &o)
+}
+
+// A Decoder reads modified UTF-8 from an underlying reader and returns it as
+// standard UTF-8, so that io.Copy(w, NewDecoder(r)) decodes a stream of any
+// size. Unlike Decode, a Decoder cannot tell up front if all of its input is
+// standard UTF-8, so it accepts raw NULs and 4-byte sequences anywhere,
+// unless the Strict option is used.
+type Decoder struct {
+	r   io.Reader
+	o   options
+	in  []byte // undecoded input
+	out []byte // decoded output not yet read
+	err error  // returned once out is drained
+
+	// buffers backing in and out.
+	inBuf  []byte
+	outBuf []byte
+
+	inOff  int64 // stream offset of in[0]
+	outLen int64 // length of the output so far
+}
+
+// NewDecoder returns a Decoder reading from r. MaxLen and MaxDecodedLen
+// apply to the stream as a whole.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	d := &Decoder{r: r, o: newOptions(opts)}
+	d.o.std = !d.o.strict
+	return d
+}
+
+// Read reads decoded UTF-8 into p. Malformed input is reported as a
+// *DecodeError, with the offset counted from the start of the stream, after
+// the output up to that point has been read.
+func (d *Decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 && d.err == nil {
+		d.fill()
+	}
+	if len(d.out) == 0 {
+		return 0, d.err
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo, decoding the rest of the stream into w
+// without copying it through an intermediate buffer. It reads the input in
+// larger chunks than Read does.
+func (d *Decoder) WriteTo(w io.Writer) (int64, error) {
+	if len(d.inBuf) < copySize {
+		d.inBuf = make([]byte, copySize)
+	}
+
+	var written int64
+	for {
+		if len(d.out) > 0 {
+			n, err := w.Write(d.out)
+			written += int64(n)
+			d.out = d.out[n:]
+			if err != nil {
+				return written, err
+			}
+		}
+		if d.err != nil {
+			break
+		}
+		d.fill()
+	}
+
+	if d.err == io.EOF {
+		return written, nil
+	}
+	return written, d.err
+}
+
+// Buffered returns the number of bytes of input that the Decoder has read
+// but not decoded yet, such as the start of a sequence that continues in the
+// next read.
+func (d *Decoder) Buffered() int {
+	return len(d.in)
+}
+
+// Peek returns the next n bytes of decoded output without consuming them,
+// reading more input if needed. The bytes are valid until the next call to
+// a method of d. If fewer than n bytes are available, it returns them along
+// with the error that stopped it, io.EOF at the end of the stream.
+func (d *Decoder) Peek(n int) ([]byte, error) {
+	for len(d.out) < n && d.err == nil {
+		d.fill()
+	}
+	if len(d.out) < n {
+		return d.out, d.err
+	}
+	return d.out[:n], nil
+}
+
+// PeekRune returns the next decoded rune and its size in bytes without
+// consuming it. At the end of the stream it returns io.EOF, or the error
+// that stopped the Decoder.
+func (d *Decoder) PeekRune() (rune, int, error) {
+	for !utf8.FullRune(d.out) && d.err == nil {
+		d.fill()
+	}
+	if len(d.out) == 0 {
+		return utf8.RuneError, 0, d.err
+	}
+	r, size := utf8.DecodeRune(d.out)
+	return r, size, nil
+}
+
+// fill reads more input and decodes as much of it as possible, appending it
+// to d.out.
+func (d *Decoder) fill() {
+	if d.inBuf == nil {
+		d.inBuf = make([]byte, bufSize)
+	}
+
+	// a partial sequence from last time is kept at the front
+	n := copy(d.inBuf, d.in)
+	m, err := d.r.Read(d.inBuf[n:])
+	in := d.inBuf[:n+m]
+	eof := err == io.EOF
+
+	var tooLarge error
+	if max := int64(d.o.maxLen); max > 0 && d.inOff+int64(len(in)) > max {
+		in = in[:max-d.inOff]
+		eof = false
+		tooLarge = streamError(in, len(in), d.inOff, ErrTooLarge)
+	}
+
+	// output not read yet is moved to the front
+	keep := len(d.out)
+	out := append(d.outBuf[:0], d.out...)
+	i := 0
+	wait := false
+	if d.o.stripBOM && d.inOff == 0 {
+		i = d.o.bomLen(in)
+		// wait for the rest of what may be a byte order mark
+		wait = i == 0 && !eof && bytes.HasPrefix(bom, in)
+	}
+	for !wait && i < len(in) {
+		span := asciiSpan(in[i:])
+		out = append(out, in[i:i+span]...)
+		if over := d.overLimit(out[keep:]); over > 0 {
+			out = out[:len(out)-over]
+			d.err = streamError(in, i+span-over, d.inOff, ErrTooLarge)
+			break
+		}
+		if i += span; i == len(in) {
+			break
+		}
+
+		n, serr := scan(in[i:])
+		if !eof && i+n == len(in) && (serr == ErrTooShort || serr == ErrTooShortSurrogate) {
+			// wait for the rest of the sequence
+			break
+		}
+
+		prev := len(out)
+		if out, serr = decodeSeq(out, in[i:i+n], serr, &d.o); serr != nil {
+			d.err = streamError(in, i, d.inOff, serr)
+			break
+		}
+		if d.overLimit(out[keep:]) > 0 {
+			out = out[:prev]
+			d.err = streamError(in, i, d.inOff, ErrTooLarge)
+			break
+		}
+		i += n
+	}
+
+	d.outBuf = out
+	d.out = out
+	d.outLen += int64(len(out) - keep)
+	d.inOff += int64(i)
+	d.in = in[i:]
+
+	switch {
+	case d.err != nil:
+	case tooLarge != nil:
+		// a sequence cut off by the limit will not be completed
+		d.err = tooLarge
+	case err != nil && !eof:
+		d.err = err
+	case eof:
+		d.err = io.EOF
+	}
+}
+
+// overLimit returns by how many bytes out takes the output past
+// MaxDecodedLen.
+func (d *Decoder) overLimit(out []byte) int {
+	if d.o.maxDecoded <= 0 {
+		return 0
+	}
+	return int(d.outLen + int64(len(out)) - int64(d.o.maxDecoded))
+}
+
+// streamError returns a *DecodeError for in[i], where in starts at offset off
+// of the stream.
+func streamError(in []byte, i int, off int64, err error) error {
+	e := newDecodeError(in, i, err)
+	e.Offset += int(off)
+	e.ctxStart += int(off)
+	return e
+}
+
+// EncodeTo writes the encoding of s to w, without building it in memory
+// first, and returns the number of bytes written. s is encoded in pieces
+// through a small buffer.
+func EncodeTo(w io.Writer, s string, opts ...Option) (int, error) {
+	o := newOptions(opts)
+
+	var scratch [bufSize]byte
+	written := 0
+	for len(s) > 0 {
+		// a byte encodes to at most 3, and runes are not split
+		n := min(len(s), bufSize/3)
+		for k := n; k < len(s) && k > n-utf8.UTFMax; k-- {
+			if utf8.RuneStart(s[k]) {
+				n = k
+				break
+			}
+		}
+
+		m, err := w.Write(encode(scratch[:0], s[:n], &o))
+		if written += m; err != nil {
+			return written, err
+		}
+		s = s[n:]
+	}
+	return written, nil
+}
+
+// An Encoder encodes standard UTF-8 written to it as modified UTF-8, and
+// writes that to an underlying writer. A rune split between two writes is
+// encoded once it is complete.
+type Encoder struct {
+	w    io.Writer
+	o    options
+	size int    // output buffer size, 0 if unbuffered
+	part []byte // start of an incomplete rune
+	buf  []byte // encoded output
+	in   []byte // input buffer for ReadFrom
+}
+
+// NewEncoder returns an Encoder writing to w. Each write is encoded and
+// written out right away. Close must be called after the last write.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return &Encoder{w: w, o: newOptions(opts)}
+}
+
+// NewEncoderSize returns an Encoder that collects up to size bytes of
+// output before writing to w, like a bufio.Writer, so that many small
+// writes do not each reach w. Flush writes out what has been buffered, and
+// Close does so as well.
+func NewEncoderSize(w io.Writer, size int, opts ...Option) *Encoder {
+	e := NewEncoder(w, opts...)
+	if size > 0 {
+		e.size = size
+		e.buf = make([]byte, 0, size)
+	}
+	return e
+}
+
+// Write encodes p and writes it to the underlying writer, in chunks of a
+// bounded size.
+func (e *Encoder) Write(p []byte) (int, error) {
+	n := 0
+	if len(e.part) > 0 {
+		// complete the runes in part with the start of p
+		var tmp [2 * utf8.UTFMax]byte
+		head := append(tmp[:0], e.part...)
+		if len(p) > utf8.UTFMax {
+			head = append(head, p[:utf8.UTFMax]...)
+		} else {
+			head = append(head, p...)
+		}
+
+		i := 0
+		for i < len(e.part) {
+			size := e.runeLen(head[i:])
+			if size == 0 {
+				break
+			}
+			i += size
+		}
+		if i > 0 {
+			if err := e.write(head[:i]); err != nil {
+				return 0, err
+			}
+		}
+		if i < len(e.part) {
+			// all of p fit in head
+			e.part = append(e.part[:0], head[i:]...)
+			return len(p), nil
+		}
+		n = i - len(e.part)
+		e.part = e.part[:0]
+	}
+
+	// hold back a trailing incomplete rune
+	end := len(p)
+	for i := len(p) - 1; i >= n && i >= len(p)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(p[i]) {
+			if e.runeLen(p[i:]) == 0 {
+				end = i
+			}
+			break
+		}
+	}
+
+	for n < end {
+		j := end
+		if j-n > bufSize {
+			j = n + bufSize
+			for j < end && !utf8.RuneStart(p[j]) {
+				j++
+			}
+		}
+		if err := e.write(p[n:j]); err != nil {
+			return n, err
+		}
+		n = j
+	}
+
+	e.part = append(e.part, p[end:]...)
+	return len(p), nil
+}
+
+// runeLen returns the length of the rune at the start of p, or 0 if it is
+// incomplete. With KeepSurrogates, that includes the 3-byte surrogates.
+func (e *Encoder) runeLen(p []byte) int {
+	if e.o.keepSurr && p[0] == 0xed && (len(p) == 1 || p[1]&0xe0 == 0xa0) {
+		switch {
+		case len(p) < 3:
+			return 0
+		case p[2]&0xc0 == 0x80:
+			return 3
+		}
+	}
+	if !utf8.FullRune(p) {
+		return 0
+	}
+	_, size := utf8.DecodeRune(p)
+	return size
+}
+
+// ReadFrom implements io.ReaderFrom, encoding everything read from r until
+// EOF through one reused input buffer. Like Write, it keeps back a rune left
+// incomplete at the end, so Close must still be called.
+func (e *Encoder) ReadFrom(r io.Reader) (int64, error) {
+	if e.in == nil {
+		e.in = make([]byte, copySize)
+	}
+
+	var n int64
+	for {
+		m, err := r.Read(e.in)
+		if m > 0 {
+			n += int64(m)
+			if _, werr := e.Write(e.in[:m]); werr != nil {
+				return n, werr
+			}
+		}
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+	}
+}
+
+// WriteString is like Write, but for a string.
+func (e *Encoder) WriteString(s string) (int, error) {
+	return e.Write(stringBytes(s))
+}
+
+// write encodes b and writes it out, or buffers it.
+func (e *Encoder) write(b []byte) error {
+	if e.size == 0 {
+		e.buf = encode(e.buf[:0], unsafe.String(&b[0], len(b)), &e.o)
+		_, err := e.w.Write(e.buf)
+		return err
+	}
+
+	e.buf = encode(e.buf, unsafe.String(&b[0], len(b)), &e.o)
+	if len(e.buf) >= e.size {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered output to the underlying writer. A rune left
+// incomplete by the last write stays buffered until it is complete, or the
+// Encoder is closed.
+func (e *Encoder) Flush() error {
+	if e.size == 0 || len(e.buf) == 0 {
+		return nil
+	}
+	n, err := e.w.Write(e.buf)
+	if n < len(e.buf) && err == nil {
+		err = io.ErrShortWrite
+	}
+	// keep what was not written for the next try
+	e.buf = e.buf[:copy(e.buf, e.buf[n:])]
+	return err
+}
+
+// Close encodes an incomplete rune left over from the last write, as
+// U+FFFD, and flushes the output. It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if len(e.part) > 0 {
+		err := e.write(e.part)
+		e.part = e.part[:0]
+		if err != nil {
+			return err
+		}
+	}
+	return e.Flush()
+}
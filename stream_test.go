@@ -0,0 +1,442 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeTo(t *testing.T) {
+	long := strings.Repeat("abc\x00\U0001f4a9", 2000)
+	tests := []struct {
+		name string
+		data []byte
+		opts []Option
+	}{
+		{"empty", nil, nil},
+		{"plain", []byte("abc"), nil},
+		{"UTF-8", []byte("a\x00\U0001f4a9"), nil},
+		{"modified", Encode("a\x00\U0001f4a9b"), nil},
+		{"long", Encode(long), nil},
+		{"long plain run", Encode("\x00" + strings.Repeat("x", 3*bufSize)), nil},
+		{"mixed", []byte{'a', 0xf0, 0x9f, 0x92, 0xa9, 0xc0, 0x80}, nil},
+		{"lossy", []byte{0xff, 'a', 0xed, 0xa0, 0xbd, 0xc0, 0x80, 0xe6}, []Option{Lossy()}},
+		{"invalid", []byte{'a', 'b', 0xc0, 0x80, 0xff, 'c'}, nil},
+		{"max len", []byte("abcd"), []Option{MaxLen(3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, wantErr := Decode(tt.data, tt.opts...)
+
+			var buf bytes.Buffer
+			n, err := DecodeTo(&buf, tt.data, tt.opts...)
+			if n != buf.Len() {
+				t.Errorf("DecodeTo() = %d, wrote %d bytes", n, buf.Len())
+			}
+			if wantErr != nil {
+				if err == nil || err.Error() != wantErr.Error() {
+					t.Errorf("DecodeTo() error = %v, want %v", err, wantErr)
+				}
+			} else if err != nil || buf.String() != want {
+				t.Errorf("DecodeTo() = %q, %v; want %q", buf.String(), err, want)
+			}
+		})
+	}
+}
+
+func TestDecodeToPartial(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := DecodeTo(&buf, []byte{'a', 0xc0, 0x80, 0xff})
+	if !errors.Is(err, ErrInvalidEncoding) || buf.String() != "a\x00" {
+		t.Errorf("DecodeTo() = %q, %v", buf.String(), err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	long := strings.Repeat("abc\x00\U0001f4a9", 2000)
+	tests := []struct {
+		name   string
+		data   []byte
+		opts   []Option
+		want   string
+		err    error
+		offset int
+	}{
+		{"empty", nil, nil, "", nil, 0},
+		{"modified", Encode("a\x00\U0001f4a9b"), nil, "a\x00\U0001f4a9b", nil, 0},
+		{"long", Encode(long), nil, long, nil, 0},
+		{"mixed", []byte{'a', 0xf0, 0x9f, 0x92, 0xa9, 0xc0, 0x80}, nil, "a\U0001f4a9\x00", nil, 0},
+		{"strict", []byte{'a', 0xc0, 0x80, 0}, []Option{Strict()}, "a\x00", ErrInvalidNUL, 3},
+		{"invalid", []byte{'a', 'b', 0xc0, 0x80, 0xff, 'c'}, nil, "ab\x00", ErrInvalidEncoding, 4},
+		{"truncated", []byte{'a', 0xed, 0xa0}, nil, "a", ErrTooShort, 1},
+		{"lossy", []byte{0xff, 'a', 0xed, 0xa0, 0xbd, 0xc0, 0x80, 0xe6}, []Option{Lossy()}, "�a�\x00�", nil, 0},
+		{"late error", append(Encode(long), 0xff), nil, long, ErrInvalidEncoding, len(Encode(long))},
+		{"max len", []byte("abcdef"), []Option{MaxLen(4)}, "abcd", ErrTooLarge, 4},
+		{"max len ok", []byte("abcd"), []Option{MaxLen(4)}, "abcd", nil, 0},
+		{"max len mid sequence", []byte("a\xc3\xa9bcd"), []Option{MaxLen(2)}, "a", ErrTooLarge, 2},
+		{"max decoded", Encode("ab\U0001f4a9c"), []Option{MaxDecodedLen(5)}, "ab", ErrTooLarge, 2},
+		{"max decoded ascii", []byte("abcdef"), []Option{MaxDecodedLen(4)}, "abcd", ErrTooLarge, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readers := map[string]func() io.Reader{
+				"whole":    func() io.Reader { return bytes.NewReader(tt.data) },
+				"one byte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(tt.data)) },
+			}
+			for name, r := range readers {
+				for _, writeTo := range []bool{false, true} {
+					var dec io.Reader = NewDecoder(r(), tt.opts...)
+					if !writeTo {
+						// hide WriteTo from io.Copy
+						dec = struct{ io.Reader }{dec}
+					}
+					var buf bytes.Buffer
+					_, err := io.Copy(&buf, dec)
+					if buf.String() != tt.want {
+						t.Errorf("%s, WriteTo %v: Decoder read %q, want %q", name, writeTo, buf.String(), tt.want)
+					}
+
+					var de *DecodeError
+					if tt.err == nil && err != nil {
+						t.Errorf("%s, WriteTo %v: Decoder error = %v", name, writeTo, err)
+					} else if tt.err != nil && (!errors.As(err, &de) || !errors.Is(err, tt.err) || de.Offset != tt.offset) {
+						t.Errorf("%s, WriteTo %v: Decoder error = %v, want %v at offset %d", name, writeTo, err, tt.err, tt.offset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderMaxLenStops(t *testing.T) {
+	data := append([]byte("a\xc3\xa9"), bytes.Repeat([]byte("b"), 100000)...)
+
+	r := &countReader{r: bytes.NewReader(data)}
+	got, err := io.ReadAll(NewDecoder(r, MaxLen(2)))
+	if string(got) != "a" || !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Decoder(MaxLen(2)) = %q, %v", got, err)
+	}
+	if r.n > bufSize {
+		t.Errorf("Decoder(MaxLen(2)) read %d bytes", r.n)
+	}
+
+	r = &countReader{r: bytes.NewReader(data)}
+	if _, err := DecodeReader(r, MaxLen(2)); !errors.Is(err, ErrTooLarge) || r.n > copySize {
+		t.Errorf("DecodeReader(MaxLen(2)) error = %v after %d bytes", err, r.n)
+	}
+}
+
+func TestDecoderReadError(t *testing.T) {
+	errTest := errors.New("test")
+	r := io.MultiReader(bytes.NewReader([]byte{'a', 0xc0, 0x80}), iotest.ErrReader(errTest))
+	got, err := io.ReadAll(NewDecoder(r))
+	if string(got) != "a\x00" || err != errTest {
+		t.Errorf("Decoder read %q, %v", got, err)
+	}
+}
+
+func TestDecoderWriteTo(t *testing.T) {
+	text := strings.Repeat("abc\x00\U0001f4a9", 20000)
+	d := NewDecoder(bytes.NewReader(Encode(text)))
+
+	// part of the output has been read already
+	head := make([]byte, 10)
+	io.ReadFull(d, head)
+
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	if got := string(head) + buf.String(); got != text || err != nil || n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, %v; output matches %v", n, err, got == text)
+	}
+
+	errTest := errors.New("test")
+	d = NewDecoder(bytes.NewReader(Encode(text)))
+	if _, err := d.WriteTo(errWriter{}); err == nil {
+		t.Errorf("WriteTo() to a failing writer returned no error")
+	}
+	r := io.MultiReader(bytes.NewReader([]byte{'a', 0xc0, 0x80}), iotest.ErrReader(errTest))
+	buf.Reset()
+	if n, err := NewDecoder(r).WriteTo(&buf); n != 2 || err != errTest || buf.String() != "a\x00" {
+		t.Errorf("WriteTo() = %d, %v; wrote %q", n, err, buf.String())
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	text := strings.Repeat("abc\x00\U0001f4a9", 20000)
+	if got, err := DecodeReader(iotest.HalfReader(bytes.NewReader(Encode(text)))); got != text || err != nil {
+		t.Errorf("DecodeReader() = %.20q, %v; want %.20q", got, err, text)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		opts []Option
+		err  error
+	}{
+		{"invalid", []byte{'a', 0xff}, nil, ErrInvalidEncoding},
+		{"strict", []byte{'a', 0}, []Option{Strict()}, ErrInvalidNUL},
+		{"max len", []byte("abcdef"), []Option{MaxLen(4)}, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		if got, err := DecodeReader(bytes.NewReader(tt.data), tt.opts...); got != "" || !errors.Is(err, tt.err) {
+			t.Errorf("DecodeReader(%s) = %q, %v; want %v", tt.name, got, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeAt(t *testing.T) {
+	data := append([]byte("head"), Encode("a\x00\U0001f4a9b")...)
+	data = append(data, 0xff, 'x')
+	r := bytes.NewReader(data)
+
+	tests := []struct {
+		name string
+		off  int64
+		n    int
+		opts []Option
+		want string
+		err  error
+	}{
+		{"plain", 0, 4, nil, "head", nil},
+		{"modified", 4, 10, nil, "a\x00\U0001f4a9b", nil},
+		{"empty", 4, 0, nil, "", nil},
+		{"invalid", 14, 2, nil, "", ErrInvalidEncoding},
+		{"max len", 0, 10, []Option{MaxLen(4)}, "", ErrTooLarge},
+		{"past end", 12, 10, nil, "", io.ErrUnexpectedEOF},
+		{"negative", 0, -1, nil, "", errNegativeLen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeAt(r, tt.off, tt.n, tt.opts...)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("DecodeAt() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+
+	var de *DecodeError
+	if _, err := DecodeAt(r, 13, 3); !errors.As(err, &de) || de.Offset != 1 {
+		t.Errorf("DecodeAt() error = %v, want offset 1", err)
+	}
+	if n := testing.AllocsPerRun(50, func() { DecodeAt(r, 0, 4) }); n != 1 {
+		t.Errorf("DecodeAt() allocates %v times, want 1", n)
+	}
+}
+
+func TestEncodeTo(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		opts []Option
+	}{
+		{"empty", "", nil},
+		{"plain", "abc", nil},
+		{"modified", "a\x00\U0001f4a9b", nil},
+		{"raw NUL", "a\x00b", []Option{RawNUL()}},
+		{"long", strings.Repeat("ab\x00\U0001f4a9", 2000), nil},
+		{"long invalid", strings.Repeat("a\xff\xed\xa0", 2000), nil},
+		{"long plain", strings.Repeat("x", 3*bufSize) + "\u65e5", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := EncodeTo(&buf, tt.str, tt.opts...)
+			if want := Encode(tt.str, tt.opts...); err != nil || n != len(want) || !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("EncodeTo() = %d, %v; output matches %v", n, err, bytes.Equal(buf.Bytes(), want))
+			}
+		})
+	}
+
+	if _, err := EncodeTo(errWriter{}, "abc"); err == nil {
+		t.Errorf("EncodeTo() to a failing writer returned no error")
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	long := strings.Repeat("abc\x00\U0001f4a9å", 2000)
+	tests := []string{
+		"",
+		"abc",
+		"a\x00\U0001f4a9b",
+		long,
+		"a\xe6\x97b\xff",
+		"\xf0\x9f\x92",
+	}
+	for _, s := range tests {
+		want := Encode(s)
+		for _, size := range []int{1, 2, 3, 5, len(s) + 1} {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			for i := 0; i < len(s); i += size {
+				j := i + size
+				if j > len(s) {
+					j = len(s)
+				}
+				if n, err := e.Write([]byte(s[i:j])); n != j-i || err != nil {
+					t.Fatalf("Write() = %d, %v", n, err)
+				}
+			}
+			if err := e.Close(); err != nil {
+				t.Fatalf("Close() = %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("Encoder(%.20q) in writes of %d = %x, want %x", s, size, buf.Bytes(), want)
+			}
+		}
+
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		n, err := e.ReadFrom(iotest.HalfReader(strings.NewReader(s)))
+		if cerr := e.Close(); n != int64(len(s)) || err != nil || cerr != nil {
+			t.Fatalf("ReadFrom() = %d, %v; Close() = %v", n, err, cerr)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("Encoder(%.20q) ReadFrom = %x, want %x", s, buf.Bytes(), want)
+		}
+	}
+}
+
+func TestEncoderReadFromError(t *testing.T) {
+	errTest := errors.New("test")
+	var buf bytes.Buffer
+	r := io.MultiReader(strings.NewReader("a\x00"), iotest.ErrReader(errTest))
+	if n, err := NewEncoder(&buf).ReadFrom(r); n != 2 || err != errTest || buf.String() != "a\xc0\x80" {
+		t.Errorf("ReadFrom() = %d, %v; wrote %q", n, err, buf.String())
+	}
+	if _, err := NewEncoder(errWriter{}).ReadFrom(strings.NewReader("abc")); err == nil {
+		t.Errorf("ReadFrom() to a failing writer returned no error")
+	}
+}
+
+// countWriter counts the writes made to it.
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestEncoderSize(t *testing.T) {
+	words := strings.Fields(strings.Repeat("a\x00 b\U0001f4a9 é こ ", 1000))
+
+	var w countWriter
+	e := NewEncoderSize(&w, 4096)
+	var want []byte
+	for _, s := range words {
+		if n, err := e.WriteString(s); n != len(s) || err != nil {
+			t.Fatalf("WriteString() = %d, %v", n, err)
+		}
+		want = append(want, Encode(s)...)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if !bytes.Equal(w.Bytes(), want) {
+		t.Errorf("buffered Encoder wrote %d bytes, want %d", w.Len(), len(want))
+	}
+	if max := len(want)/4096 + 1; w.writes > max {
+		t.Errorf("buffered Encoder made %d writes, want at most %d", w.writes, max)
+	}
+}
+
+func TestEncoderFlush(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoderSize(&buf, 100)
+	e.Write([]byte("a\x00\xf0\x9f"))
+	if buf.Len() != 0 {
+		t.Errorf("Encoder wrote %q before Flush", buf.String())
+	}
+
+	// the incomplete rune stays behind
+	if err := e.Flush(); err != nil || buf.String() != "a\xc0\x80" {
+		t.Errorf("Flush() = %v, wrote %q", err, buf.String())
+	}
+	e.Write([]byte("\x92\xa9"))
+	if err := e.Close(); err != nil || buf.String() != "a\xc0\x80\xed\xa0\xbd\xed\xb2\xa9" {
+		t.Errorf("Close() = %v, wrote %q", err, buf.String())
+	}
+
+	// unbuffered Encoders have nothing to flush
+	if err := NewEncoder(errWriter{}).Flush(); err != nil {
+		t.Errorf("Flush() = %v", err)
+	}
+	e = NewEncoderSize(errWriter{}, 100)
+	e.WriteString("abc")
+	if err := e.Close(); err == nil {
+		t.Errorf("Close() to a failing writer returned no error")
+	}
+}
+
+func TestDecoderPeek(t *testing.T) {
+	d := NewDecoder(iotest.OneByteReader(bytes.NewReader(Encode("a\x00\U0001f4a9bc"))))
+
+	if r, size, err := d.PeekRune(); r != 'a' || size != 1 || err != nil {
+		t.Errorf("PeekRune() = %q, %d, %v", r, size, err)
+	}
+	if p, err := d.Peek(6); string(p) != "a\x00\U0001f4a9" || err != nil {
+		t.Errorf("Peek(6) = %q, %v", p, err)
+	}
+
+	// a read leaving part of a rune does not lose it
+	p := make([]byte, 3)
+	if n, _ := d.Read(p); string(p[:n]) != "a\x00\xf0" {
+		t.Fatalf("Read() = %q", p[:n])
+	}
+	if p, err := d.Peek(5); string(p) != "\x9f\x92\xa9bc" || err != nil {
+		t.Errorf("Peek(5) = %q, %v", p, err)
+	}
+	if p, err := d.Peek(10); string(p) != "\x9f\x92\xa9bc" || err != io.EOF {
+		t.Errorf("Peek(10) = %q, %v", p, err)
+	}
+	rest, err := io.ReadAll(d)
+	if string(rest) != "\x9f\x92\xa9bc" || err != nil {
+		t.Errorf("ReadAll() = %q, %v", rest, err)
+	}
+	if r, size, err := d.PeekRune(); size != 0 || err != io.EOF {
+		t.Errorf("PeekRune() at end = %q, %d, %v", r, size, err)
+	}
+
+	d = NewDecoder(bytes.NewReader([]byte{'a', 0xff}))
+	if r, _, err := d.PeekRune(); r != 'a' || err != nil {
+		t.Errorf("PeekRune() = %q, %v", r, err)
+	}
+	d.Read(make([]byte, 1))
+	if _, _, err := d.PeekRune(); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("PeekRune() error = %v", err)
+	}
+}
+
+func TestDecoderBuffered(t *testing.T) {
+	r, w := io.Pipe()
+	d := NewDecoder(r)
+	go func() {
+		w.Write([]byte{'a', 0xed, 0xa0})
+		w.Write([]byte{0xbd, 0xed, 0xb2, 0xa9})
+		w.Close()
+	}()
+
+	p := make([]byte, 10)
+	if n, err := d.Read(p); string(p[:n]) != "a" || err != nil || d.Buffered() != 2 {
+		t.Errorf("Read() = %q, %v; Buffered() = %d", p[:n], err, d.Buffered())
+	}
+	if n, err := d.Read(p); string(p[:n]) != "\U0001f4a9" || err != nil || d.Buffered() != 0 {
+		t.Errorf("Read() = %q, %v; Buffered() = %d", p[:n], err, d.Buffered())
+	}
+}
+
+func TestDecoderPeekLimit(t *testing.T) {
+	d := NewDecoder(iotest.OneByteReader(strings.NewReader("abcdef")), MaxDecodedLen(4))
+	io.ReadFull(d, make([]byte, 3))
+	if p, err := d.Peek(3); string(p) != "d" || !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Peek(3) = %q, %v", p, err)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "unicode/utf8"
+
+// Surrogate ranges of UTF-16.
+const (
+	surrHigh = 0xd800 // first high surrogate
+	surrLow  = 0xdc00 // first low surrogate
+	surrEnd  = 0xe000 // end of the low surrogates
+	surrSelf = 0x10000
+)
+
+// IsHighSurrogate reports whether c is the first half of a surrogate pair.
+func IsHighSurrogate(c uint16) bool {
+	return c >= surrHigh && c < surrLow
+}
+
+// IsLowSurrogate reports whether c is the second half of a surrogate pair.
+func IsLowSurrogate(c uint16) bool {
+	return c >= surrLow && c < surrEnd
+}
+
+// EncodeSurrogatePair returns the surrogate pair for the supplementary
+// character r. Other runes give U+FFFD twice, as utf16.EncodeRune.
+func EncodeSurrogatePair(r rune) (hi, lo uint16) {
+	if r < surrSelf || r > utf8.MaxRune {
+		return utf8.RuneError, utf8.RuneError
+	}
+	r -= surrSelf
+	return uint16(surrHigh + r>>10), uint16(surrLow + r&0x3ff)
+}
+
+// DecodeSurrogatePair returns the supplementary character for the pair hi,
+// lo. If they are not a high and a low surrogate, it returns U+FFFD.
+func DecodeSurrogatePair(hi, lo uint16) rune {
+	if !IsHighSurrogate(hi) || !IsLowSurrogate(lo) {
+		return utf8.RuneError
+	}
+	return surrSelf + rune(hi-surrHigh)<<10 | rune(lo-surrLow)
+}
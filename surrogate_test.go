@@ -0,0 +1,57 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+func TestSurrogatePair(t *testing.T) {
+	for _, r := range []rune{0x10000, 0x10437, 0x1f4a9, 0x10ffff} {
+		hi, lo := EncodeSurrogatePair(r)
+		if !IsHighSurrogate(hi) || !IsLowSurrogate(lo) {
+			t.Errorf("EncodeSurrogatePair(%U) = %x, %x", r, hi, lo)
+		}
+		if h, l := utf16.EncodeRune(r); rune(hi) != h || rune(lo) != l {
+			t.Errorf("EncodeSurrogatePair(%U) = %x, %x; want %x, %x", r, hi, lo, h, l)
+		}
+		if got := DecodeSurrogatePair(hi, lo); got != r {
+			t.Errorf("DecodeSurrogatePair(%x, %x) = %U, want %U", hi, lo, got, r)
+		}
+	}
+
+	for _, r := range []rune{-1, 0, 'a', 0xd800, 0xffff, 0x110000} {
+		if hi, lo := EncodeSurrogatePair(r); hi != 0xfffd || lo != 0xfffd {
+			t.Errorf("EncodeSurrogatePair(%U) = %x, %x; want fffd, fffd", r, hi, lo)
+		}
+	}
+	for _, p := range [][2]uint16{{'a', 'b'}, {0xdc00, 0xd800}, {0xd800, 0xd800}, {0xdfff, 0xe000}} {
+		if got := DecodeSurrogatePair(p[0], p[1]); got != 0xfffd {
+			t.Errorf("DecodeSurrogatePair(%x, %x) = %U, want U+FFFD", p[0], p[1], got)
+		}
+	}
+}
+
+func TestIsSurrogate(t *testing.T) {
+	tests := []struct {
+		c         uint16
+		high, low bool
+	}{
+		{0, false, false},
+		{0xd7ff, false, false},
+		{0xd800, true, false},
+		{0xdbff, true, false},
+		{0xdc00, false, true},
+		{0xdfff, false, true},
+		{0xe000, false, false},
+		{0xffff, false, false},
+	}
+	for _, tt := range tests {
+		if IsHighSurrogate(tt.c) != tt.high || IsLowSurrogate(tt.c) != tt.low {
+			t.Errorf("IsHighSurrogate, IsLowSurrogate(%x) = %v, %v; want %v, %v",
+				tt.c, IsHighSurrogate(tt.c), IsLowSurrogate(tt.c), tt.high, tt.low)
+		}
+	}
+}
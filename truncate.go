@@ -0,0 +1,62 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+// Truncate returns the longest prefix of b that is at most max bytes long
+// and ends at a sequence boundary, never splitting a multi-byte sequence or
+// a surrogate pair. It is useful for fitting strings into fixed-size fields
+// such as the 65535 bytes allowed by DataOutputStream.writeUTF.
+func Truncate(b []byte, max int) []byte {
+	if len(b) <= max {
+		return b
+	}
+	if max <= 0 {
+		return b[:0]
+	}
+
+	// back up to the start of the sequence that doesn't fit, unless these
+	// are stray continuation bytes
+	k := max
+	for k > 0 && k > max-3 && b[k]&0xc0 == 0x80 {
+		k--
+	}
+	if b[k]&0xc0 == 0x80 {
+		k = max
+	}
+
+	// and to the start of a pair, if it's the second half of one
+	if k >= 3 && k+1 < len(b) && b[k] == 0xed && b[k+1]&0xf0 == 0xb0 && b[k-3] == 0xed && b[k-2]&0xf0 == 0xa0 {
+		k -= 3
+	}
+	return b[:k]
+}
+
+// TruncateUTF16 returns the longest prefix of b that decodes to at most n
+// UTF-16 code units, i.e. Java chars, never splitting a surrogate pair. It
+// is for limits counted in chars, as String.length does.
+func TruncateUTF16(b []byte, n int) []byte {
+	i := 0
+	for i < len(b) && n > 0 {
+		if span := asciiSpan(b[i:]); span > 0 {
+			if span > n {
+				span = n
+			}
+			i += span
+			n -= span
+			continue
+		}
+
+		size, err := scan(b[i:])
+		chars := 1
+		if err == nil && size == 6 || err == ErrFourByte {
+			chars = 2
+		}
+		if chars > n {
+			break
+		}
+		i += size
+		n -= chars
+	}
+	return b[:i]
+}
@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	// a, NUL, é, こ, U+1F4A9 take 1, 2, 2, 3 and 6 bytes
+	b := Encode("a\x00éこ\U0001f4a9b")
+	tests := []struct {
+		max  int
+		want string
+	}{
+		{-1, ""},
+		{0, ""},
+		{1, "a"},
+		{2, "a"},
+		{3, "a\x00"},
+		{4, "a\x00"},
+		{5, "a\x00é"},
+		{7, "a\x00é"},
+		{8, "a\x00éこ"},
+		{10, "a\x00éこ"},
+		{11, "a\x00éこ"},
+		{13, "a\x00éこ"},
+		{14, "a\x00éこ\U0001f4a9"},
+		{15, "a\x00éこ\U0001f4a9b"},
+		{100, "a\x00éこ\U0001f4a9b"},
+	}
+	for _, tt := range tests {
+		got := Truncate(b, tt.max)
+		if s, err := Decode(got); err != nil || s != tt.want {
+			t.Errorf("Truncate(%d) = %x (%q, %v), want %q", tt.max, got, s, err, tt.want)
+		}
+	}
+
+	stray := []byte{'a', 0x80, 0x80, 0x80, 0x80, 0x80}
+	if got := Truncate(stray, 5); len(got) != 5 {
+		t.Errorf("Truncate(%x, 5) = %x", stray, got)
+	}
+
+	// a lead byte at the very end
+	for _, tt := range []struct {
+		b    []byte
+		max  int
+		want []byte
+	}{
+		{[]byte("abcd\xed"), 4, []byte("abcd")},
+		{[]byte{0xed, 0xa0, 0xbd, 0xed}, 3, []byte{0xed, 0xa0, 0xbd}},
+	} {
+		if got := Truncate(tt.b, tt.max); !bytes.Equal(got, tt.want) {
+			t.Errorf("Truncate(%x, %d) = %x, want %x", tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateUTF16(t *testing.T) {
+	b := Encode("a\x00éこ\U0001f4a9b")
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{-1, ""},
+		{0, ""},
+		{1, "a"},
+		{4, "a\x00éこ"},
+		{5, "a\x00éこ"},
+		{6, "a\x00éこ\U0001f4a9"},
+		{7, "a\x00éこ\U0001f4a9b"},
+		{100, "a\x00éこ\U0001f4a9b"},
+	}
+	for _, tt := range tests {
+		got := TruncateUTF16(b, tt.n)
+		if s, err := Decode(got); err != nil || s != tt.want {
+			t.Errorf("TruncateUTF16(%d) = %x (%q, %v), want %q", tt.n, got, s, err, tt.want)
+		}
+	}
+
+	// 4-byte sequences are two chars as well
+	if got := TruncateUTF16([]byte("a\U0001f4a9"), 2); string(got) != "a" {
+		t.Errorf("TruncateUTF16(4-byte, 2) = %q", got)
+	}
+}
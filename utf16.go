@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errOddLength = errors.New("odd length UTF-16 data")
+
+// ToUTF16 converts b to the UTF-16 chars it encodes, as in a Java char
+// array. Unlike Decode, unpaired surrogates are kept as they are. Raw NULs
+// and 4-byte sequences are accepted as well; other malformed input results
+// in a *DecodeError.
+func ToUTF16(b []byte) ([]uint16, error) {
+	u := make([]uint16, 0, len(b))
+	r := charReader{d: b}
+	for {
+		c, ok := r.next()
+		if !ok {
+			return u, nil
+		}
+		if r.bad != nil {
+			_, err := scan(r.bad)
+			return nil, newDecodeError(b, r.i-len(r.bad), err)
+		}
+		u = append(u, c)
+	}
+}
+
+// FromUTF16 returns the modified UTF-8 encoding of the UTF-16 chars in u.
+// Each char is encoded on its own, so surrogate pairs become the 6-byte form
+// and unpaired surrogates are kept, the way Java encodes a String.
+func FromUTF16(u []uint16) []byte {
+	n := 0
+	for _, c := range u {
+		switch {
+		case c == 0 || c >= 0x80 && c < 0x800:
+			n += 2
+		case c < 0x80:
+			n++
+		default:
+			n += 3
+		}
+	}
+
+	b := make([]byte, 0, n)
+	for _, c := range u {
+		b = appendRune(b, rune(c))
+	}
+	return b
+}
+
+// ToUTF16Bytes is like ToUTF16, but returns the chars as bytes in the given
+// byte order, the way DataOutput.writeChars writes them.
+func ToUTF16Bytes(b []byte, order binary.ByteOrder) ([]byte, error) {
+	u := make([]byte, 0, 2*len(b))
+	r := charReader{d: b}
+	for {
+		c, ok := r.next()
+		if !ok {
+			return u, nil
+		}
+		if r.bad != nil {
+			_, err := scan(r.bad)
+			return nil, newDecodeError(b, r.i-len(r.bad), err)
+		}
+		u = append(u, 0, 0)
+		order.PutUint16(u[len(u)-2:], c)
+	}
+}
+
+// FromUTF16Bytes is like FromUTF16, but for chars stored as bytes in the
+// given byte order. It fails if u has an odd length.
+func FromUTF16Bytes(u []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(u)%2 != 0 {
+		return nil, errOddLength
+	}
+
+	b := make([]byte, 0, len(u)/2*3)
+	for i := 0; i < len(u); i += 2 {
+		b = appendRune(b, rune(order.Uint16(u[i:])))
+	}
+	return b, nil
+}
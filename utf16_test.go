@@ -0,0 +1,73 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUTF16(t *testing.T) {
+	tests := []struct {
+		b []byte
+		u []uint16
+	}{
+		{[]byte{}, []uint16{}},
+		{[]byte("abc"), []uint16{'a', 'b', 'c'}},
+		{Encode("a\x00éこ\U0001f4a9"), []uint16{'a', 0, 0xe9, 0x3053, 0xd83d, 0xdca9}},
+		{[]byte{0xed, 0xa0, 0xbd, 'x'}, []uint16{0xd83d, 'x'}},
+		{[]byte{'x', 0xed, 0xb2, 0xa9}, []uint16{'x', 0xdca9}},
+		{[]byte{0xed, 0xb2, 0xa9, 0xed, 0xa0, 0xbd}, []uint16{0xdca9, 0xd83d}},
+	}
+	for _, tt := range tests {
+		if got, err := ToUTF16(tt.b); !reflect.DeepEqual(got, tt.u) || err != nil {
+			t.Errorf("ToUTF16(%x) = %x, %v; want %x", tt.b, got, err, tt.u)
+		}
+		if got := FromUTF16(tt.u); !bytes.Equal(got, tt.b) || cap(got) != len(got) {
+			t.Errorf("FromUTF16(%x) = %x (cap %d), want %x", tt.u, got, cap(got), tt.b)
+		}
+	}
+}
+
+func TestToUTF16Standard(t *testing.T) {
+	got, err := ToUTF16([]byte("a\x00\U0001f4a9"))
+	if want := []uint16{'a', 0, 0xd83d, 0xdca9}; !reflect.DeepEqual(got, want) || err != nil {
+		t.Errorf("ToUTF16() = %x, %v; want %x", got, err, want)
+	}
+
+	_, err = ToUTF16([]byte{'a', 'b', 0xff})
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Offset != 2 || !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("ToUTF16(ff) error = %v", err)
+	}
+}
+
+func TestUTF16Bytes(t *testing.T) {
+	b := Encode("a\x00é\U0001f4a9")
+	be := []byte{0, 'a', 0, 0, 0, 0xe9, 0xd8, 0x3d, 0xdc, 0xa9, 0xd8, 0x00}
+	le := []byte{'a', 0, 0, 0, 0xe9, 0, 0x3d, 0xd8, 0xa9, 0xdc, 0x00, 0xd8}
+	b = append(b, 0xed, 0xa0, 0x80)
+
+	for _, tt := range []struct {
+		order binary.ByteOrder
+		u     []byte
+	}{{binary.BigEndian, be}, {binary.LittleEndian, le}} {
+		if got, err := ToUTF16Bytes(b, tt.order); !bytes.Equal(got, tt.u) || err != nil {
+			t.Errorf("ToUTF16Bytes(%x, %v) = %x, %v; want %x", b, tt.order, got, err, tt.u)
+		}
+		if got, err := FromUTF16Bytes(tt.u, tt.order); !bytes.Equal(got, b) || err != nil {
+			t.Errorf("FromUTF16Bytes(%x, %v) = %x, %v; want %x", tt.u, tt.order, got, err, b)
+		}
+	}
+
+	if _, err := ToUTF16Bytes([]byte{0xc0}, binary.BigEndian); !errors.Is(err, ErrTooShort) {
+		t.Errorf("ToUTF16Bytes(c0) error = %v", err)
+	}
+	if _, err := FromUTF16Bytes([]byte{0, 'a', 0}, binary.BigEndian); err == nil {
+		t.Errorf("FromUTF16Bytes() of odd length returned no error")
+	}
+}
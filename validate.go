@@ -0,0 +1,113 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import "unicode/utf8"
+
+// A Problem is a malformed region of the input reported by ValidateAll.
+type Problem struct {
+	Offset int   // byte offset of the region
+	Len    int   // length of the region in bytes
+	Err    error // one of the Err* values
+}
+
+// Valid reports whether b is canonical modified UTF-8, i.e. whether Decode
+// with the Strict option would succeed.
+func Valid(b []byte) bool {
+	for i := 0; i < len(b); {
+		if i += asciiSpan(b[i:]); i == len(b) {
+			break
+		}
+
+		n, err := scan(b[i:])
+		if err != nil {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// IsCanonical reports whether b is the same in modified and standard UTF-8,
+// i.e. valid and without encoded NULs or surrogate pairs. Decoding such input
+// is a no-op, so b can be used as UTF-8 directly.
+func IsCanonical(b []byte) bool {
+	for i := 0; i < len(b); {
+		if i += asciiSpan(b[i:]); i == len(b) {
+			break
+		}
+
+		n, err := scan(b[i:])
+		if err != nil || n == 6 || b[i] == 0xc0 {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// ValidateAll scans all of b and returns every region that is not canonical
+// modified UTF-8, in order. Adjacent problems of the same kind are merged
+// into one. The result is nil if b is valid.
+func ValidateAll(b []byte) []Problem {
+	var problems []Problem
+
+	for i := 0; i < len(b); {
+		n, err := scan(b[i:])
+		if err != nil {
+			if k := len(problems) - 1; k >= 0 && problems[k].Err == err && problems[k].Offset+problems[k].Len == i {
+				problems[k].Len += n
+			} else {
+				problems = append(problems, Problem{Offset: i, Len: n, Err: err})
+			}
+		}
+		i += n
+	}
+
+	return problems
+}
+
+// A Fix is a change made by Repair: the problem found in the input and the
+// bytes it was replaced with.
+type Fix struct {
+	Problem
+	Replacement []byte
+}
+
+// Repair returns a copy of b rewritten into canonical modified UTF-8, along
+// with the changes made. Raw NUL bytes and 4-byte sequences are converted to
+// their modified forms, other malformed sequences become U+FFFD. The fixes
+// are nil if b was already valid.
+func Repair(b []byte) ([]byte, []Fix) {
+	var fixes []Fix
+	out := make([]byte, 0, len(b))
+
+	for i := 0; i < len(b); {
+		n, err := scan(b[i:])
+		if err == nil {
+			out = append(out, b[i:i+n]...)
+			i += n
+			continue
+		}
+
+		start := len(out)
+		switch err {
+		case ErrInvalidNUL:
+			out = appendRune(out, 0)
+		case ErrFourByte:
+			r, _ := utf8.DecodeRune(b[i:])
+			out = appendRune(out, r)
+		default:
+			out = appendRune(out, utf8.RuneError)
+		}
+
+		fixes = append(fixes, Fix{
+			Problem:     Problem{Offset: i, Len: n, Err: err},
+			Replacement: out[start:len(out):len(out)],
+		})
+		i += n
+	}
+
+	return out, fixes
+}
@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Anders Bergh <anders1@gmail.com>
+// MIT license (see LICENSE).
+
+package jutf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateAll(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []Problem
+	}{
+		{"valid", []byte{'a', 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, nil},
+		{"raw NUL", []byte{0, 0, 'a', 0}, []Problem{{0, 2, ErrInvalidNUL}, {3, 1, ErrInvalidNUL}}},
+		{"four byte", []byte("a\U0001f4a9"), []Problem{{1, 4, ErrFourByte}}},
+		{"garbage", []byte{0xff, 0xfe, 0x80, 'a', 0xc1, 0x81}, []Problem{{0, 3, ErrInvalidEncoding}, {4, 2, ErrInvalidEncoding}}},
+		{"lone surrogates", []byte{0xed, 0xb2, 0xa9, 0xed, 0xa0, 0xbd, 'a'}, []Problem{{0, 6, ErrUnpairedSurrogate}}},
+		{"cut off", []byte{'a', 0xe6, 0x97}, []Problem{{1, 2, ErrTooShort}}},
+		{"cut off pair", []byte{'a', 0xed, 0xa0, 0xbd, 0xed}, []Problem{{1, 4, ErrTooShortSurrogate}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateAll(tt.data)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateAll() = %v, want %v", got, tt.want)
+			}
+			if valid := Valid(tt.data); valid != (tt.want == nil) {
+				t.Errorf("Valid() = %v", valid)
+			}
+		})
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", []byte{}, true},
+		{"text", []byte("java/lang/Object åäö 日本語"), true},
+		{"NUL", []byte{'a', 0xc0, 0x80}, false},
+		{"surrogate pair", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}, false},
+		{"raw NUL", []byte{'a', 0}, false},
+		{"four byte", []byte("\U0001f4a9"), false},
+		{"invalid", []byte{0xff}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCanonical(tt.data); got != tt.want {
+				t.Errorf("IsCanonical() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		want  []byte
+		fixes []Fix
+	}{
+		{"valid", []byte{'a', 0xc0, 0x80}, []byte{'a', 0xc0, 0x80}, nil},
+		{"raw NUL", []byte{'a', 0}, []byte{'a', 0xc0, 0x80},
+			[]Fix{{Problem{1, 1, ErrInvalidNUL}, []byte{0xc0, 0x80}}}},
+		{"four byte", []byte("\U0001f4a9!"), []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9, '!'},
+			[]Fix{{Problem{0, 4, ErrFourByte}, []byte{0xed, 0xa0, 0xbd, 0xed, 0xb2, 0xa9}}}},
+		{"garbage", []byte{0xff, 'a', 0xed, 0xa0, 0xbd}, []byte("�a�"),
+			[]Fix{{Problem{0, 1, ErrInvalidEncoding}, []byte("�")}, {Problem{2, 3, ErrTooShortSurrogate}, []byte("�")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fixes := Repair(tt.data)
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(fixes, tt.fixes) {
+				t.Errorf("Repair() = %x, %v; want %x, %v", got, fixes, tt.want, tt.fixes)
+			}
+			if !Valid(got) {
+				t.Errorf("Repair() = %x is not valid", got)
+			}
+		})
+	}
+}